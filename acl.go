@@ -0,0 +1,254 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/superloach/confy"
+)
+
+// KeyACL is the Confy key used to load/store ACL Rules.
+const KeyACL = "acl"
+
+// ErrDenied occurs when an ACL Rule denies a Cmd to the invoker.
+var ErrDenied = errors.New("you can't use that command here")
+
+// Rule allows or denies a Cmd or category to a user, role and/or channel.
+//
+// Exactly one of Cmd or Cat should be set. Cmd is the full name of a Cmd, like "prefix set",
+// and also matches its subcommands. Any of User, Role and Channel may be set, and all that
+// are set must match; if none are, the Rule matches everyone.
+type Rule struct {
+	Cmd string `json:"cmd,omitempty"`
+	Cat string `json:"cat,omitempty"`
+
+	User    discord.UserID    `json:"user,omitempty"`
+	Role    discord.RoleID    `json:"role,omitempty"`
+	Channel discord.ChannelID `json:"channel,omitempty"`
+
+	Allow bool `json:"allow"`
+}
+
+// Subject is who is running a Cmd, and where, for matching Rules.
+type Subject struct {
+	User    discord.UserID
+	Roles   []discord.RoleID
+	Channel discord.ChannelID
+}
+
+// match checks whether the Rule applies to the given Cmd path, category, and Subject,
+// and gets how specific it is.
+//
+// Rules for users are more specific than those for roles, which are more specific than
+// those for channels. Between Rules for the same kind of Subject, Rules for a Cmd are more
+// specific than those for a category, and deeper Cmds more than their parents.
+func (r Rule) match(path, cat string, s Subject) (int, bool) {
+	var target int
+
+	switch {
+	case r.Cmd != "" && cmdMatch(r.Cmd, path):
+		target = 1 + strings.Count(r.Cmd, " ")
+	case r.Cmd == "" && r.Cat != "" && r.Cat == cat:
+		target = 0
+	default:
+		return 0, false
+	}
+
+	subject := 0
+
+	if r.User.IsValid() {
+		if r.User != s.User {
+			return 0, false
+		}
+
+		subject |= 4
+	}
+
+	if r.Role.IsValid() {
+		if !hasRole(s.Roles, r.Role) {
+			return 0, false
+		}
+
+		subject |= 2
+	}
+
+	if r.Channel.IsValid() {
+		if r.Channel != s.Channel {
+			return 0, false
+		}
+
+		subject |= 1
+	}
+
+	return subject<<8 | target, true
+}
+
+func hasRole(roles []discord.RoleID, role discord.RoleID) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ACLStore is a concurrent-safe store of Guild-specific ACL Rules.
+type ACLStore struct {
+	Confy confy.Confy
+
+	ma map[discord.GuildID][]Rule
+	mu sync.RWMutex
+}
+
+// OpenACLStore creates a usable ACLStore and calls Load.
+func OpenACLStore(c confy.Confy) (*ACLStore, error) {
+	acls := &ACLStore{
+		Confy: c,
+
+		ma: map[discord.GuildID][]Rule{},
+		mu: sync.RWMutex{},
+	}
+
+	if err := acls.Load(); err != nil {
+		return nil, fmt.Errorf("acls load: %w", err)
+	}
+
+	return acls, nil
+}
+
+// Load updates the ACLStore with data from the Confy.
+//
+// It is not an error for the Confy to have no data yet.
+func (a *ACLStore) Load() error {
+	a.mu.Lock()
+	err := confyLoad(a.Confy, KeyACL, &a.ma)
+	a.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyACL, err)
+	}
+
+	return nil
+}
+
+// Store updates the Confy with data from the ACLStore.
+func (a *ACLStore) Store() error {
+	a.mu.RLock()
+	err := a.Confy.Set(KeyACL, a.ma)
+	a.mu.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyACL, err)
+	}
+
+	return nil
+}
+
+// Get allows looking up the Rules for a GuildID.
+func (a *ACLStore) Get(g discord.GuildID) ([]Rule, bool) {
+	a.mu.RLock()
+	rules, ok := a.ma[g]
+	a.mu.RUnlock()
+
+	return append([]Rule(nil), rules...), ok
+}
+
+// Set allows storing the Rules for a given GuildID.
+func (a *ACLStore) Set(g discord.GuildID, rules []Rule) {
+	a.mu.Lock()
+	a.ma[g] = append([]Rule(nil), rules...)
+	a.mu.Unlock()
+}
+
+// Add appends Rules to those for a given GuildID.
+func (a *ACLStore) Add(g discord.GuildID, rules ...Rule) {
+	a.mu.Lock()
+	a.ma[g] = append(a.ma[g], rules...)
+	a.mu.Unlock()
+}
+
+// Del removes the Rules for the given GuildID from the ACLStore.
+func (a *ACLStore) Del(g discord.GuildID) {
+	a.mu.Lock()
+	delete(a.ma, g)
+	a.mu.Unlock()
+}
+
+// Allowed checks whether the Rules allow the Cmd with the given full name and category
+// to the Subject, in the given Guild.
+//
+// The most specific matching Rule decides, with deny winning ties. If no Rules for the Guild
+// match, those of GlobalGuildID are used, and if none of those match either, the Cmd is allowed.
+func (a *ACLStore) Allowed(g discord.GuildID, path, cat string, s Subject) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, rules := range [][]Rule{a.ma[g], a.ma[GlobalGuildID]} {
+		best, allow, found := 0, true, false
+
+		for _, r := range rules {
+			score, ok := r.match(path, cat, s)
+			if !ok {
+				continue
+			}
+
+			if !found || score > best || (score == best && !r.Allow) {
+				best, allow, found = score, r.Allow, true
+			}
+		}
+
+		if found {
+			return allow
+		}
+
+		if g == GlobalGuildID {
+			break
+		}
+	}
+
+	return true
+}
+
+// needsRoles checks whether any Rules for the Guild (or GlobalGuildID) match by role.
+func (a *ACLStore) needsRoles(g discord.GuildID) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, rules := range [][]Rule{a.ma[g], a.ma[GlobalGuildID]} {
+		for _, r := range rules {
+			if r.Role.IsValid() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkACL checks that the Route's ACLStore allows the Trigger's Cmd to be run.
+func (t *Trigger) checkACL() error {
+	s := Subject{
+		User:    t.Message.Author.ID,
+		Roles:   nil,
+		Channel: t.Message.ChannelID,
+	}
+
+	if t.Message.GuildID.IsValid() && t.Route.ACL.needsRoles(t.Message.GuildID) {
+		m, err := t.Route.State.Member(t.Message.GuildID, s.User)
+		if err != nil {
+			return fmt.Errorf("get member: %w", err)
+		}
+
+		s.Roles = m.RoleIDs
+	}
+
+	if !t.Route.ACL.Allowed(t.Message.GuildID, t.PathName(), t.Command.Cat, s) {
+		return ErrDenied
+	}
+
+	return nil
+}
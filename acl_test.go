@@ -0,0 +1,170 @@
+package route_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+	"github.com/superloach/confy"
+
+	"github.com/go-snart/route"
+)
+
+const (
+	testACLGuild   = 123
+	testACLChannel = 456
+	testACLUser    = 999
+	testACLRole    = 789
+)
+
+func TestACLAllowed(t *testing.T) {
+	t.Parallel()
+
+	acls, err := route.OpenACLStore(confy.NewMem())
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	mod := route.Subject{User: testACLUser, Roles: []discord.RoleID{testACLRole}, Channel: testACLChannel}
+	other := route.Subject{User: 1, Roles: nil, Channel: testACLChannel}
+
+	if !acls.Allowed(testACLGuild, "ban", "mod", mod) {
+		t.Error("expect allow without rules")
+	}
+
+	acls.Set(testACLGuild, []route.Rule{
+		{Cat: "mod", Allow: false},
+		{Cat: "mod", Role: testACLRole, Allow: true},
+		{Cmd: "ban", Role: testACLRole, Allow: false},
+		{Cmd: "ban", User: testACLUser, Allow: true},
+		{Cmd: "prefix", Channel: testACLChannel, Allow: false},
+		{Cmd: "prefix get", Channel: testACLChannel, Allow: true},
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		cat    string
+		s      route.Subject
+		expect bool
+	}{
+		{"everyone denied cat", "kick", "mod", other, false},
+		{"role beats everyone", "kick", "mod", mod, true},
+		{"user beats role", "ban", "mod", mod, true},
+		{"cmd beats cat", "ban", "mod", route.Subject{User: 2, Roles: []discord.RoleID{testACLRole}}, false},
+		{"sub inherits", "prefix set", "", other, false},
+		{"deeper cmd wins", "prefix get", "", other, true},
+		{"no match", "help", "", other, true},
+	}
+
+	for _, test := range tests {
+		if got := acls.Allowed(testACLGuild, test.path, test.cat, test.s); got != test.expect {
+			t.Errorf("%s: expect %t, got %t", test.name, test.expect, got)
+		}
+	}
+}
+
+func TestACLDenyWinsTies(t *testing.T) {
+	t.Parallel()
+
+	acls, err := route.OpenACLStore(confy.NewMem())
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	acls.Add(testACLGuild,
+		route.Rule{Cmd: "ban", Allow: true},
+		route.Rule{Cmd: "ban", Allow: false},
+		route.Rule{Cmd: "ban", Allow: true},
+	)
+
+	if acls.Allowed(testACLGuild, "ban", "", route.Subject{User: 1}) {
+		t.Error("expect deny")
+	}
+}
+
+func TestACLGlobalFallback(t *testing.T) {
+	t.Parallel()
+
+	c := confy.NewMem()
+
+	acls, err := route.OpenACLStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	acls.Set(route.GlobalGuildID, []route.Rule{{Cmd: "eval", Allow: false}})
+	acls.Set(testACLGuild, []route.Rule{{Cmd: "ban", Allow: false}})
+
+	err = acls.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	acls2, err := route.OpenACLStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	s := route.Subject{User: 1}
+
+	if acls2.Allowed(testACLGuild, "eval", "", s) {
+		t.Error("expect global deny")
+	}
+
+	if acls2.Allowed(testACLGuild, "ban", "", s) {
+		t.Error("expect guild deny")
+	}
+
+	if !acls2.Allowed(1, "ban", "", s) {
+		t.Error("expect other guild allow")
+	}
+}
+
+func TestHandleACL(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	run := ""
+
+	cmd, _ := testCmd()
+	cmd.Func = testFunc(&run)
+	r.Cmd.Add(cmd)
+
+	r.ACL.Set(testACLGuild, []route.Rule{{Cmd: testName, Role: testACLRole, Allow: false}})
+
+	m.Me(testMe)
+	m.Member(testACLGuild, testMMe)
+	m.Member(testACLGuild, discord.Member{
+		User:    discord.User{ID: testACLUser},
+		RoleIDs: []discord.RoleID{testACLRole},
+	})
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: route.ErrDenied.Error(),
+		},
+		discord.Message{
+			ChannelID: testACLChannel,
+		},
+	)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testACLGuild,
+			ChannelID: testACLChannel,
+			Author: discord.User{
+				ID: testACLUser,
+			},
+			Content: testPfx.Value + testName,
+		},
+	})
+
+	if run != "" {
+		t.Errorf("expect no run, got %q", run)
+	}
+
+	m.Eval()
+}
@@ -0,0 +1,103 @@
+package route
+
+import (
+	"sync"
+	"time"
+)
+
+// autoStore holds the state of a PrefixStore's automatic persistence.
+type autoStore struct {
+	interval time.Duration
+	onErr    func(error)
+	timer    *time.Timer
+	dirty    bool
+	mu       sync.Mutex
+}
+
+// AutoStore makes the PrefixStore call Store by itself once it has had no changes for
+// the given interval, so that bursts of changes are written together.
+// Errors from storing in the background are passed to onErr, if it isn't nil.
+//
+// By default, Store must be called manually. Flush or Close should be called before exiting,
+// to store any pending changes.
+func (p *PrefixStore) AutoStore(interval time.Duration, onErr func(error)) {
+	p.auto.mu.Lock()
+	p.auto.interval = interval
+	p.auto.onErr = onErr
+	p.auto.mu.Unlock()
+}
+
+// changed schedules a Store after a change, if AutoStore is enabled.
+func (p *PrefixStore) changed() {
+	p.auto.mu.Lock()
+	defer p.auto.mu.Unlock()
+
+	if p.auto.interval <= 0 {
+		return
+	}
+
+	p.auto.dirty = true
+
+	if p.auto.timer == nil {
+		p.auto.timer = time.AfterFunc(p.auto.interval, p.autoFlush)
+
+		return
+	}
+
+	p.auto.timer.Reset(p.auto.interval)
+}
+
+func (p *PrefixStore) autoFlush() {
+	err := p.Flush()
+	if err == nil {
+		return
+	}
+
+	p.auto.mu.Lock()
+	onErr := p.auto.onErr
+	p.auto.mu.Unlock()
+
+	if onErr != nil {
+		onErr(err)
+	}
+}
+
+// Flush calls Store right away if there are changes waiting to be stored by AutoStore.
+// If storing fails, the changes are kept waiting, to be retried by the next change or Flush.
+func (p *PrefixStore) Flush() error {
+	p.auto.mu.Lock()
+
+	if p.auto.timer != nil {
+		p.auto.timer.Stop()
+		p.auto.timer = nil
+	}
+
+	dirty := p.auto.dirty
+	p.auto.dirty = false
+
+	p.auto.mu.Unlock()
+
+	if !dirty {
+		return nil
+	}
+
+	err := p.Store()
+	if err != nil {
+		p.auto.mu.Lock()
+		p.auto.dirty = true
+		p.auto.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Close stops AutoStore, and calls Flush to store any changes that were waiting.
+func (p *PrefixStore) Close() error {
+	p.auto.mu.Lock()
+	p.auto.interval = 0
+	p.auto.mu.Unlock()
+
+	return p.Flush()
+}
@@ -0,0 +1,177 @@
+package route_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/superloach/confy"
+
+	"github.com/go-snart/route"
+)
+
+const testAutoInterval = 20 * time.Millisecond
+
+var errTestConfy = errors.New("confy broke")
+
+// testCountConfy counts how many times prefixes are stored, and can be made to fail.
+type testCountConfy struct {
+	confy.Confy
+
+	mu     sync.Mutex
+	stores int
+	fail   bool
+}
+
+func (c *testCountConfy) Set(key string, val interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail {
+		return errTestConfy
+	}
+
+	if key == route.KeyPrefix {
+		c.stores++
+	}
+
+	return c.Confy.Set(key, val)
+}
+
+func (c *testCountConfy) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stores
+}
+
+func (c *testCountConfy) setFail(fail bool) {
+	c.mu.Lock()
+	c.fail = fail
+	c.mu.Unlock()
+}
+
+func testAutoStore(t *testing.T) (*route.PrefixStore, *testCountConfy) {
+	t.Helper()
+
+	c := &testCountConfy{Confy: testConfy()}
+
+	pfxs, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	return pfxs, c
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutoStoreManual(t *testing.T) {
+	t.Parallel()
+
+	pfxs, c := testAutoStore(t)
+
+	pfxs.Set(1, "!")
+	time.Sleep(5 * testAutoInterval)
+
+	if n := c.count(); n != 0 {
+		t.Errorf("expect no stores by default, got %d", n)
+	}
+
+	err := pfxs.Flush()
+	if err != nil || c.count() != 0 {
+		t.Errorf("expect flush to do nothing, got %d (%v)", c.count(), err)
+	}
+}
+
+func TestAutoStoreDebounce(t *testing.T) {
+	t.Parallel()
+
+	pfxs, c := testAutoStore(t)
+	pfxs.AutoStore(testAutoInterval, nil)
+
+	pfxs.Set(1, "!")
+	pfxs.Add(1, "?")
+	pfxs.SetUser(2, "yo ")
+
+	waitFor(t, func() bool { return c.count() > 0 })
+	time.Sleep(5 * testAutoInterval)
+
+	if n := c.count(); n != 1 {
+		t.Errorf("expect changes to be stored together, got %d stores", n)
+	}
+
+	pfxs2, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if pfxvs, _ := pfxs2.GetAll(1); len(pfxvs) != 2 {
+		t.Errorf("expect stored prefixes, got %q", pfxvs)
+	}
+
+	if pfxvs, _ := pfxs2.GetUserAll(2); len(pfxvs) != 1 {
+		t.Errorf("expect stored user prefixes, got %q", pfxvs)
+	}
+}
+
+func TestAutoStoreClose(t *testing.T) {
+	t.Parallel()
+
+	pfxs, c := testAutoStore(t)
+	pfxs.AutoStore(time.Hour, nil)
+
+	pfxs.Set(1, "!")
+
+	err := pfxs.Close()
+	if err != nil || c.count() != 1 {
+		t.Fatalf("expect close to store, got %d (%v)", c.count(), err)
+	}
+
+	pfxs.Del(1)
+
+	err = pfxs.Flush()
+	if err != nil || c.count() != 1 {
+		t.Errorf("expect no auto store after close, got %d (%v)", c.count(), err)
+	}
+}
+
+func TestAutoStoreError(t *testing.T) {
+	t.Parallel()
+
+	pfxs, c := testAutoStore(t)
+
+	errs := make(chan error, 1)
+	pfxs.AutoStore(testAutoInterval, func(err error) { errs <- err })
+
+	c.setFail(true)
+	pfxs.Set(1, "!")
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errTestConfy) {
+			t.Errorf("expect errTestConfy, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect error callback")
+	}
+
+	c.setFail(false)
+
+	err := pfxs.Flush()
+	if err != nil || c.count() != 1 {
+		t.Errorf("expect flush to retry, got %d (%v)", c.count(), err)
+	}
+}
@@ -0,0 +1,102 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"flag"
+)
+
+// Class is a classification of errors from handling commands.
+type Class int
+
+const (
+	// ClassIgnore is for errors that happen in normal use, like lines without a prefix.
+	ClassIgnore Class = iota
+
+	// ClassUser is for errors caused by the invoking user, like unknown commands or bad flags.
+	ClassUser
+
+	// ClassInternal is for errors that are the bot's fault. Unknown errors are ClassInternal.
+	ClassInternal
+)
+
+// String gets the name of the Class.
+func (c Class) String() string {
+	switch c {
+	case ClassIgnore:
+		return "ignore"
+	case ClassUser:
+		return "user"
+	case ClassInternal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// Classifier is an error that knows its own Class.
+type Classifier interface {
+	error
+	Class() Class
+}
+
+//nolint:gochecknoglobals // fixed lookup tables
+var (
+	ignoreErrs = []error{ErrNoLinePrefix, ErrNoCmd, flag.ErrHelp, context.Canceled, ErrShutdown}
+	userErrs   = []error{
+		ErrCmdNotFound, ErrArgs, ErrConvert, ErrUnterminated, ErrCmdTimeout, ErrCmdDisabled,
+		ErrGuildOnly, ErrDMOnly, ErrNSFWOnly, ErrOwnerOnly, ErrDenied,
+	}
+)
+
+// Classify gets the Class of an error.
+//
+// Errors (or errors they wrap) implementing Classifier decide for themselves.
+// Otherwise, the errors defined by this package are classified as documented by each Class.
+func Classify(err error) Class {
+	if err == nil {
+		return ClassIgnore
+	}
+
+	var c Classifier
+	if errors.As(err, &c) {
+		return c.Class()
+	}
+
+	for _, e := range ignoreErrs {
+		if errors.Is(err, e) {
+			return ClassIgnore
+		}
+	}
+
+	for _, e := range userErrs {
+		if errors.Is(err, e) {
+			return ClassUser
+		}
+	}
+
+	return ClassInternal
+}
+
+// FlagError is an error from parsing flags. Usage has already been shown to the user.
+type FlagError struct {
+	Err error
+}
+
+func (f *FlagError) Error() string {
+	return f.Err.Error()
+}
+
+// Unwrap gets the error from package flag.
+func (f *FlagError) Unwrap() error {
+	return f.Err
+}
+
+// Class gets the Class of the FlagError, which is ClassIgnore for -help and ClassUser otherwise.
+func (f *FlagError) Class() Class {
+	if errors.Is(f.Err, flag.ErrHelp) {
+		return ClassIgnore
+	}
+
+	return ClassUser
+}
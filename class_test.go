@@ -0,0 +1,71 @@
+package route_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+type testClassErr struct{}
+
+func (testClassErr) Error() string      { return "classed" }
+func (testClassErr) Class() route.Class { return route.ClassUser }
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	for err, expect := range map[error]route.Class{
+		nil:                   route.ClassIgnore,
+		route.ErrNoLinePrefix: route.ClassIgnore,
+		route.ErrNoCmd:        route.ClassIgnore,
+		flag.ErrHelp:          route.ClassIgnore,
+		route.ErrCmdNotFound:  route.ClassUser,
+		route.ErrArgs:         route.ClassUser,
+		route.ErrCmdTimeout:   route.ClassUser,
+		io.EOF:                route.ClassInternal,
+		testClassErr{}:        route.ClassUser,
+		fmt.Errorf("wrap: %w", route.ErrCmdNotFound): route.ClassUser,
+		fmt.Errorf("wrap: %w", testClassErr{}):       route.ClassUser,
+	} {
+		if got := route.Classify(err); got != expect {
+			t.Errorf("classify %v: expect %s, got %s", err, expect, got)
+		}
+	}
+}
+
+func TestClassifyFlagParse(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	const (
+		channel = 1234567890
+		line    = "//cmd -nope"
+	)
+
+	// parse errors reply with usage
+	m.Error(http.MethodPost, "/channels/1234567890/messages", httputil.HTTPError{Status: 500})
+
+	_, err := r.Trigger(testPfx, discord.Message{ChannelID: channel, Content: line}, line)
+	if err == nil {
+		t.Fatalf("expect parse error, got nil")
+	}
+
+	if got := route.Classify(err); got != route.ClassUser {
+		t.Errorf("expect %s, got %s", route.ClassUser, got)
+	}
+
+	m.Eval()
+}
@@ -1,67 +1,300 @@
 package route
 
 import (
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
 )
 
+// ErrCmdConflict occurs when a Cmd's name or alias is already used by another Cmd.
+var ErrCmdConflict = errors.New("command name conflict")
+
 // CmdStore is a concurrent-safe store of Cmds.
 type CmdStore struct {
+	// Fold enables case-insensitive, Unicode-normalised lookups of names and aliases.
+	// It should be set before any Cmds are added.
+	Fold bool
+
 	ma map[string]Cmd
+	al map[string]string
 	mu sync.RWMutex
 }
 
 // NewCmdStore creates a usable CmdStore.
 func NewCmdStore() *CmdStore {
 	return &CmdStore{
+		Fold: false,
+
 		ma: map[string]Cmd{},
+		al: map[string]string{},
 		mu: sync.RWMutex{},
 	}
 }
 
-// Add stores a Cmd, using its defined name.
-func (c *CmdStore) Add(cmd Cmd) {
+func (c *CmdStore) key(name string) string {
+	if !c.Fold {
+		return name
+	}
+
+	return cases.Fold().String(norm.NFKC.String(name))
+}
+
+// Add stores a Cmd, using its defined name and aliases.
+//
+// A Cmd with the same name is replaced. If the name or any alias is used by another Cmd,
+// or subcommands of the Cmd conflict with each other, ErrCmdConflict is returned.
+func (c *CmdStore) Add(cmd Cmd) error {
+	err := c.checkSubs(cmd)
+	if err != nil {
+		return err
+	}
+
+	key := c.key(cmd.Name)
+
 	c.mu.Lock()
-	c.ma[cmd.Name] = cmd
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		k := c.key(name)
+
+		if other, ok := c.al[k]; ok && other != key {
+			return fmt.Errorf("%w: %q is an alias of %q", ErrCmdConflict, name, c.ma[other].Name)
+		}
+
+		if _, ok := c.ma[k]; ok && k != key {
+			return fmt.Errorf("%w: %q is a command", ErrCmdConflict, name)
+		}
+	}
+
+	c.del(key)
+
+	c.ma[key] = cmd
+	for _, alias := range cmd.Aliases {
+		c.al[c.key(alias)] = key
+	}
+
+	return nil
 }
 
-// Get fetches a Cmd with the given name.
+func (c *CmdStore) checkSubs(cmd Cmd) error {
+	seen := map[string]string{}
+
+	for _, sub := range cmd.Subs {
+		for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+			k := c.key(name)
+
+			if other, ok := seen[k]; ok {
+				return fmt.Errorf("%w: %q of %q is used by %q", ErrCmdConflict, name, cmd.Name, other)
+			}
+
+			seen[k] = sub.Name
+		}
+
+		err := c.checkSubs(sub)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get fetches a Cmd with the given name or alias.
 func (c *CmdStore) Get(name string) (Cmd, bool) {
+	key := c.key(name)
+
 	c.mu.RLock()
-	cmd, ok := c.ma[name]
-	c.mu.RUnlock()
+	defer c.mu.RUnlock()
+
+	if cmd, ok := c.ma[key]; ok {
+		return cmd, true
+	}
+
+	cmd, ok := c.ma[c.al[key]]
 
 	return cmd, ok
 }
 
-// Del removes a Cmd with the given name.
+// Sub finds a subcommand of the given Cmd by name or alias, using the CmdStore's lookup rules.
+//
+// Subcommands without a Cat inherit the Cat of their parent.
+func (c *CmdStore) Sub(cmd Cmd, name string) (Cmd, bool) {
+	return cmd.sub(c.key, name)
+}
+
+// Del removes a Cmd with the given name, along with its aliases.
 func (c *CmdStore) Del(name string) {
 	c.mu.Lock()
-	delete(c.ma, name)
+	c.del(c.key(name))
 	c.mu.Unlock()
 }
 
+func (c *CmdStore) del(key string) {
+	old, ok := c.ma[key]
+	if !ok {
+		return
+	}
+
+	for _, alias := range old.Aliases {
+		delete(c.al, c.key(alias))
+	}
+
+	delete(c.ma, key)
+}
+
 // Cmd is a command.
 type Cmd struct {
-	Name  string
-	Desc  string
-	Cat   string
-	Func  Func
-	Hide  bool
+	Name    string
+	Aliases []string
+	Desc    string
+	Cat     string
+	Func    Func
+	Hide    bool
+
+	// Flags is a struct describing the flags of the Cmd, for go-flagsfiller.
+	// If it is nil, the Cmd has no flags.
 	Flags interface{}
+
+	// Pos is a struct describing the positional arguments of the Cmd, with `pos` tags.
+	// If it is nil, positional arguments are left unchecked in Trigger.Args.
+	Pos interface{}
+
+	// Parser selects how Flags are parsed, overriding Route.Parser.
+	Parser Parser
+
+	// Middleware wraps Func, inside any Route and category Middleware.
+	// It also wraps the Funcs of Subs, outside their own Middleware.
+	Middleware []Middleware
+
+	// Perms are the Permissions the invoker needs in the channel to run the Cmd.
+	Perms discord.Permissions
+	// BotPerms are the Permissions the bot needs in the channel to run the Cmd.
+	BotPerms discord.Permissions
+
+	// GuildOnly and DMOnly restrict where the Cmd may be run.
+	GuildOnly bool
+	DMOnly    bool
+	// NSFW restricts the Cmd to NSFW channels.
+	NSFW bool
+	// OwnerOnly restricts the Cmd to Route.Owners.
+	OwnerOnly bool
+
+	// Cooldowns limit how often the Cmd may be run. All of them must have a use left.
+	// They also apply to Subs, which share them with each other and with the Cmd.
+	Cooldowns []Cooldown
+
+	// Timeout limits how long Func may run, overriding Route.Timeout. Zero means use the Route's.
+	// It also applies to Subs that don't set their own.
+	Timeout time.Duration
+
+	// Subs are subcommands, invoked by name after this Cmd and its Flags.
+	// A Cmd with Subs may leave Func nil if it can't be run on its own.
+	Subs []Cmd
+}
+
+// Sub finds the subcommand with the given name or alias.
+//
+// Subcommands without a Cat inherit the Cat of this Cmd.
+func (cmd Cmd) Sub(name string) (Cmd, bool) {
+	return cmd.sub(func(s string) string { return s }, name)
+}
+
+func (cmd Cmd) sub(key func(string) string, name string) (Cmd, bool) {
+	k := key(name)
+
+	for _, sub := range cmd.Subs {
+		if !sub.is(key, k) {
+			continue
+		}
+
+		if sub.Cat == "" {
+			sub.Cat = cmd.Cat
+		}
+
+		return sub, true
+	}
+
+	return Cmd{}, false
+}
+
+func (cmd Cmd) is(key func(string) string, k string) bool {
+	if key(cmd.Name) == k {
+		return true
+	}
+
+	for _, alias := range cmd.Aliases {
+		if key(alias) == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Flatten gets this Cmd and all of its subcommands, depth-first.
+//
+// The Name of each subcommand is replaced with its full path, like "prefix set".
+// Subcommands inherit Hide from their parents, and Cat if they don't have their own.
+func (cmd Cmd) Flatten() []Cmd {
+	cmds := []Cmd{cmd}
+
+	for _, sub := range cmd.Subs {
+		if sub.Cat == "" {
+			sub.Cat = cmd.Cat
+		}
+
+		sub.Name = cmd.Name + " " + sub.Name
+		sub.Hide = sub.Hide || cmd.Hide
+
+		cmds = append(cmds, sub.Flatten()...)
+	}
+
+	return cmds
+}
+
+// cmdMatch checks whether the full name path is of the Cmd with the full name name,
+// or one of its subcommands.
+func cmdMatch(name, path string) bool {
+	return path == name || strings.HasPrefix(path, name+" ")
 }
 
 // ByCat creates a map of sorted Cmd categories, and a sorted list of category names.
+// Subcommands are included by their full path, as given by Flatten.
 //
 // If hidden is true, Cmds with the Hide flag will be included.
 func (c *CmdStore) ByCat(hidden bool) (map[string][]Cmd, []string) {
+	return c.byCat(hidden, func(Cmd) bool { return true })
+}
+
+// ByCatFor is like ByCat, but leaves out Cmds disabled in the given Guild and channel,
+// according to the given ConfStore.
+func (c *CmdStore) ByCatFor(
+	hidden bool,
+	confs *ConfStore,
+	g discord.GuildID,
+	ch discord.ChannelID,
+) (map[string][]Cmd, []string) {
+	return c.byCat(hidden, func(cmd Cmd) bool {
+		return !confs.Disabled(g, ch, cmd.Name, cmd.Cat)
+	})
+}
+
+func (c *CmdStore) byCat(hidden bool, keep func(Cmd) bool) (map[string][]Cmd, []string) {
 	cats := make(map[string][]Cmd)
 
 	c.mu.RLock()
-	for _, cmd := range c.ma {
-		if !cmd.Hide || hidden {
-			cats[cmd.Cat] = append(cats[cmd.Cat], cmd)
+	for _, top := range c.ma {
+		for _, cmd := range top.Flatten() {
+			if (!cmd.Hide || hidden) && keep(cmd) {
+				cats[cmd.Cat] = append(cats[cmd.Cat], cmd)
+			}
 		}
 	}
 	c.mu.RUnlock()
@@ -1,6 +1,13 @@
 package route_test
 
 import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/superloach/confy"
+
 	"github.com/go-snart/route"
 )
 
@@ -16,3 +23,172 @@ func testCmd() (route.Cmd, *string) {
 		Flags: testFlags{},
 	}, &run
 }
+
+type testSubFlags struct {
+	Global bool `usage:"apply globally"`
+}
+
+func testTreeCmd() (route.Cmd, *[]string) {
+	called := []string(nil)
+
+	return route.Cmd{
+		Name:  "prefix",
+		Desc:  "manage prefixes",
+		Cat:   testCat,
+		Flags: testSubFlags{},
+		Subs: []route.Cmd{
+			{
+				Name:  "set",
+				Desc:  "set the prefix",
+				Flags: testFlags{},
+				Func: func(t *route.Trigger) error {
+					called = append(called, "set "+t.Flags.(testFlags).Run)
+
+					return nil
+				},
+			},
+			{
+				Name: "secret",
+				Hide: true,
+				Func: func(*route.Trigger) error {
+					called = append(called, "secret")
+
+					return nil
+				},
+				Flags: testFlags{},
+			},
+		},
+	}, &called
+}
+
+func TestByCatTree(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewCmdStore()
+
+	cmd, _ := testTreeCmd()
+	c.Add(cmd)
+
+	cats, names := c.ByCat(false)
+	if len(names) != 1 || names[0] != testCat {
+		t.Fatalf("expect cats %v, got %v", []string{testCat}, names)
+	}
+
+	got := []string(nil)
+	for _, cmd := range cats[testCat] {
+		got = append(got, cmd.Name)
+	}
+
+	expect := []string{"prefix", "prefix set"}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect %v\ngot %v", expect, got)
+	}
+
+	cats, _ = c.ByCat(true)
+	if len(cats[testCat]) != 3 {
+		t.Errorf("expect 3 cmds with hidden, got %d", len(cats[testCat]))
+	}
+}
+
+func TestByCatFor(t *testing.T) {
+	t.Parallel()
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	c := route.NewCmdStore()
+
+	cmd, _ := testTreeCmd()
+	c.Add(cmd)
+
+	confs, err := route.OpenConfStore(confy.NewMem())
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	confs.SetDisabled(guild, route.GuildDisabled{
+		Channels: map[discord.ChannelID]route.Disabled{
+			channel: {Cmds: []string{"prefix set"}},
+		},
+	})
+
+	cats, _ := c.ByCatFor(false, confs, guild, channel)
+	if len(cats[testCat]) != 1 || cats[testCat][0].Name != "prefix" {
+		t.Errorf("expect only %q, got %v", "prefix", cats[testCat])
+	}
+
+	cats, _ = c.ByCatFor(false, confs, guild, 1)
+	if len(cats[testCat]) != 2 {
+		t.Errorf("expect 2 cmds in other channel, got %d", len(cats[testCat]))
+	}
+}
+
+func TestAddAliases(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewCmdStore()
+
+	cmd, _ := testCmd()
+	cmd.Aliases = []string{"c", "command"}
+
+	err := c.Add(cmd)
+	if err != nil {
+		t.Fatalf("add: %s", err)
+	}
+
+	for _, name := range []string{testName, "c", "command"} {
+		if got, ok := c.Get(name); !ok || got.Name != testName {
+			t.Errorf("get %q: expect %q, got %q (%t)", name, testName, got.Name, ok)
+		}
+	}
+
+	if _, ok := c.Get("CMD"); ok {
+		t.Errorf("get %q: expect !ok without fold", "CMD")
+	}
+
+	other, _ := testCmd()
+	other.Name = "other"
+	other.Aliases = []string{"c"}
+
+	err = c.Add(other)
+	if !errors.Is(err, route.ErrCmdConflict) {
+		t.Errorf("expect %v\ngot %v", route.ErrCmdConflict, err)
+	}
+
+	c.Del(testName)
+
+	if _, ok := c.Get("c"); ok {
+		t.Errorf("get %q: expect !ok after del", "c")
+	}
+}
+
+func TestAddFold(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewCmdStore()
+	c.Fold = true
+
+	cmd, _ := testCmd()
+	cmd.Aliases = []string{"Straße"}
+
+	err := c.Add(cmd)
+	if err != nil {
+		t.Fatalf("add: %s", err)
+	}
+
+	for _, name := range []string{"CMD", "Cmd", "STRASSE", "ｃｍｄ"} {
+		if _, ok := c.Get(name); !ok {
+			t.Errorf("get %q: expect ok with fold", name)
+		}
+	}
+
+	tree, _ := testTreeCmd()
+	tree.Subs = append(tree.Subs, route.Cmd{Name: "SET"})
+
+	err = c.Add(tree)
+	if !errors.Is(err, route.ErrCmdConflict) {
+		t.Errorf("expect %v\ngot %v", route.ErrCmdConflict, err)
+	}
+}
@@ -0,0 +1,225 @@
+package route
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/superloach/confy"
+)
+
+// KeyGuildConf is the Confy key used to load/store guild configurations.
+const KeyGuildConf = "guildconf"
+
+// KeyDisabled is the Confy key used to load/store disabled Cmds and categories.
+const KeyDisabled = "disabled"
+
+// GuildConf is the configuration of the Route for a Guild.
+type GuildConf struct {
+	// Suggest enables replying with similar commands when a command isn't found.
+	Suggest bool `json:"suggest,omitempty"`
+
+	// NoMention disables mentions of the bot as prefixes.
+	NoMention bool `json:"nomention,omitempty"`
+}
+
+// GuildDisabled holds the Cmds and categories disabled in a Guild.
+//
+// It is kept apart from GuildConf, so disabling something in a Guild doesn't stop
+// the GuildConf of GlobalGuildID from applying there.
+type GuildDisabled struct {
+	// Disabled holds the Cmds and categories disabled in every channel of the Guild.
+	Disabled Disabled `json:"disabled,omitempty"`
+
+	// Channels holds the Cmds and categories disabled in specific channels of the Guild,
+	// in addition to Disabled.
+	Channels map[discord.ChannelID]Disabled `json:"channels,omitempty"`
+}
+
+// Disabled lists Cmds, by full name like "prefix set", and categories that can't be run.
+// Disabling a Cmd also disables its subcommands.
+type Disabled struct {
+	Cmds []string `json:"cmds,omitempty"`
+	Cats []string `json:"cats,omitempty"`
+}
+
+// Has checks whether the Cmd with the given full name and category is disabled.
+func (d Disabled) Has(path, cat string) bool {
+	for _, name := range d.Cmds {
+		if cmdMatch(name, path) {
+			return true
+		}
+	}
+
+	if cat == "" {
+		return false
+	}
+
+	for _, c := range d.Cats {
+		if c == cat {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ConfStore is a concurrent-safe store of GuildConfs and GuildDisableds.
+type ConfStore struct {
+	Confy confy.Confy
+
+	ma  map[discord.GuildID]GuildConf
+	dis map[discord.GuildID]GuildDisabled
+	mu  sync.RWMutex
+}
+
+// OpenConfStore creates a usable ConfStore and calls Load.
+func OpenConfStore(c confy.Confy) (*ConfStore, error) {
+	confs := &ConfStore{
+		Confy: c,
+
+		ma:  map[discord.GuildID]GuildConf{},
+		dis: map[discord.GuildID]GuildDisabled{},
+		mu:  sync.RWMutex{},
+	}
+
+	if err := confs.Load(); err != nil {
+		return nil, fmt.Errorf("confs load: %w", err)
+	}
+
+	return confs, nil
+}
+
+// Load updates the ConfStore with data from the Confy.
+//
+// It is not an error for the Confy to have no data yet.
+func (c *ConfStore) Load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := confyLoad(c.Confy, KeyGuildConf, &c.ma)
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyGuildConf, err)
+	}
+
+	err = confyLoad(c.Confy, KeyDisabled, &c.dis)
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyDisabled, err)
+	}
+
+	return nil
+}
+
+// Store updates the Confy with data from the ConfStore.
+func (c *ConfStore) Store() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	err := c.Confy.Set(KeyGuildConf, c.ma)
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyGuildConf, err)
+	}
+
+	err = c.Confy.Set(KeyDisabled, c.dis)
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyDisabled, err)
+	}
+
+	return nil
+}
+
+// Get allows looking up a GuildConf by GuildID.
+func (c *ConfStore) Get(g discord.GuildID) (GuildConf, bool) {
+	c.mu.RLock()
+	conf, ok := c.ma[g]
+	c.mu.RUnlock()
+
+	return conf, ok
+}
+
+// Set allows storing a GuildConf for a given GuildID.
+func (c *ConfStore) Set(g discord.GuildID, conf GuildConf) {
+	c.mu.Lock()
+	c.ma[g] = conf
+	c.mu.Unlock()
+}
+
+// Del removes the GuildConf for the given GuildID from the ConfStore.
+func (c *ConfStore) Del(g discord.GuildID) {
+	c.mu.Lock()
+	delete(c.ma, g)
+	c.mu.Unlock()
+}
+
+// For gets the GuildConf for the given GuildID, falling back to that of GlobalGuildID.
+func (c *ConfStore) For(g discord.GuildID) GuildConf {
+	conf, ok := c.Get(g)
+	if !ok {
+		conf, _ = c.Get(GlobalGuildID)
+	}
+
+	return conf
+}
+
+// GetDisabled allows looking up the GuildDisabled for a GuildID.
+func (c *ConfStore) GetDisabled(g discord.GuildID) (GuildDisabled, bool) {
+	c.mu.RLock()
+	dis, ok := c.dis[g]
+	c.mu.RUnlock()
+
+	return dis, ok
+}
+
+// SetDisabled allows storing the GuildDisabled for a given GuildID.
+func (c *ConfStore) SetDisabled(g discord.GuildID, dis GuildDisabled) {
+	c.mu.Lock()
+	c.dis[g] = dis
+	c.mu.Unlock()
+}
+
+// DelDisabled removes the GuildDisabled for the given GuildID from the ConfStore.
+func (c *ConfStore) DelDisabled(g discord.GuildID) {
+	c.mu.Lock()
+	delete(c.dis, g)
+	c.mu.Unlock()
+}
+
+// Disabled checks whether the Cmd with the given full name and category is disabled
+// in the given Guild and channel.
+//
+// Cmds disabled for GlobalGuildID are disabled everywhere, and those disabled
+// for the Guild are disabled in all of its channels.
+func (c *ConfStore) Disabled(g discord.GuildID, ch discord.ChannelID, path, cat string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.dis[GlobalGuildID].Disabled.Has(path, cat) {
+		return true
+	}
+
+	if !g.IsValid() {
+		return false
+	}
+
+	dis := c.dis[g]
+
+	return dis.Disabled.Has(path, cat) || dis.Channels[ch].Has(path, cat)
+}
+
+// confyLoad is like Confy.Get, but leaves ptr alone if the key has never been set.
+func confyLoad(c confy.Confy, key string, ptr interface{}) error {
+	keys, err := c.Keys()
+	if err != nil {
+		return fmt.Errorf("keys: %w", err)
+	}
+
+	norm := confy.NormKey(key)
+
+	for _, k := range keys {
+		if k == norm {
+			return c.Get(key, ptr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package route_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/superloach/confy"
+
+	"github.com/go-snart/route"
+)
+
+func TestConfStoreEmpty(t *testing.T) {
+	t.Parallel()
+
+	confs, err := route.OpenConfStore(confy.NewMem())
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if conf, ok := confs.Get(route.GlobalGuildID); ok {
+		t.Errorf("expect !ok, got %#v", conf)
+	}
+}
+
+func TestConfStoreFor(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	c := confy.NewMem()
+
+	confs, err := route.OpenConfStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	confs.Set(route.GlobalGuildID, route.GuildConf{Suggest: true})
+
+	if !confs.For(guild).Suggest {
+		t.Errorf("expect global fallback")
+	}
+
+	confs.Set(guild, route.GuildConf{Suggest: false})
+
+	if confs.For(guild).Suggest {
+		t.Errorf("expect guild override")
+	}
+
+	err = confs.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	confs2, err := route.OpenConfStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if !confs2.For(route.GlobalGuildID).Suggest || confs2.For(guild).Suggest {
+		t.Errorf("expect stored confs to load")
+	}
+}
+
+func TestConfStoreDisabled(t *testing.T) {
+	t.Parallel()
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	confs, err := route.OpenConfStore(confy.NewMem())
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	confs.SetDisabled(route.GlobalGuildID, route.GuildDisabled{
+		Disabled: route.Disabled{Cmds: []string{"eval"}},
+	})
+	confs.SetDisabled(guild, route.GuildDisabled{
+		Disabled: route.Disabled{Cats: []string{"nsfw"}},
+		Channels: map[discord.ChannelID]route.Disabled{
+			channel: {Cmds: []string{"prefix"}, Cats: []string{"fun"}},
+		},
+	})
+
+	tests := []struct {
+		name   string
+		guild  discord.GuildID
+		ch     discord.ChannelID
+		path   string
+		cat    string
+		expect bool
+	}{
+		{"global cmd", guild, channel, "eval", "", true},
+		{"global cmd in dm", 0, channel, "eval", "", true},
+		{"guild cat", guild, 1, "boobs", "nsfw", true},
+		{"channel cat", guild, channel, "meme", "fun", true},
+		{"channel cat elsewhere", guild, 1, "meme", "fun", false},
+		{"channel sub", guild, channel, "prefix set", "", true},
+		{"not a sub", guild, channel, "prefixes", "", false},
+		{"other guild", 1, channel, "meme", "fun", false},
+	}
+
+	for _, test := range tests {
+		if got := confs.Disabled(test.guild, test.ch, test.path, test.cat); got != test.expect {
+			t.Errorf("%s: expect %t, got %t", test.name, test.expect, got)
+		}
+	}
+}
+
+func TestConfStoreDisabledKeepsGlobal(t *testing.T) {
+	t.Parallel()
+
+	const guild = 123
+
+	c := confy.NewMem()
+
+	confs, err := route.OpenConfStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	confs.Set(route.GlobalGuildID, route.GuildConf{NoMention: true})
+	confs.SetDisabled(guild, route.GuildDisabled{
+		Disabled: route.Disabled{Cmds: []string{"eval"}},
+	})
+
+	if !confs.For(guild).NoMention {
+		t.Error("expect global NoMention in guild with disabled cmds")
+	}
+
+	err = confs.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	confs2, err := route.OpenConfStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if !confs2.Disabled(guild, 1, "eval", "") {
+		t.Error("expect stored disabled cmds to load")
+	}
+
+	if !confs2.For(guild).NoMention {
+		t.Error("expect global NoMention after load")
+	}
+}
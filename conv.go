@@ -0,0 +1,268 @@
+package route
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	ff "github.com/itzg/go-flagsfiller"
+)
+
+// ErrConvert occurs when an argument can't be converted to the type it's meant to be.
+var ErrConvert = errors.New("invalid argument")
+
+//nolint:gochecknoglobals // type lookups
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	skipType     = reflect.TypeOf(struct{}{})
+)
+
+// Converter turns an argument into a value, for a given Trigger.
+type Converter = func(t *Trigger, arg string) (interface{}, error)
+
+// ConvStore is a concurrent-safe store of Converters, by the type they produce.
+type ConvStore struct {
+	ma map[reflect.Type]Converter
+	mu sync.RWMutex
+}
+
+// NewConvStore creates a usable ConvStore, with Converters for Discord entities.
+//
+// Strings, bools, numbers and time.Durations are always supported, and don't need Converters.
+func NewConvStore() *ConvStore {
+	c := &ConvStore{
+		ma: map[reflect.Type]Converter{},
+		mu: sync.RWMutex{},
+	}
+
+	c.addEntities()
+
+	return c
+}
+
+// Add stores a Converter for the given type.
+func (c *ConvStore) Add(typ reflect.Type, conv Converter) {
+	c.mu.Lock()
+	c.ma[typ] = conv
+	c.mu.Unlock()
+}
+
+// Get fetches the Converter for the given type.
+func (c *ConvStore) Get(typ reflect.Type) (Converter, bool) {
+	c.mu.RLock()
+	conv, ok := c.ma[typ]
+	c.mu.RUnlock()
+
+	return conv, ok
+}
+
+// Del removes the Converter for the given type.
+func (c *ConvStore) Del(typ reflect.Type) {
+	c.mu.Lock()
+	delete(c.ma, typ)
+	c.mu.Unlock()
+}
+
+// Convert turns an argument into a value of the given type, for the given Trigger.
+//
+// Errors wrap ErrConvert, except when the Converter fails for an internal reason,
+// like ErrNoState or a LookupError, which is wrapped as-is.
+func (c *ConvStore) Convert(t *Trigger, typ reflect.Type, arg string) (reflect.Value, error) {
+	if conv, ok := c.Get(typ); ok {
+		v, err := conv(t, arg)
+		if isInternal(err) {
+			return reflect.Value{}, fmt.Errorf("convert %s: %w", typ, err)
+		}
+
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%w: %s", ErrConvert, err)
+		}
+
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().ConvertibleTo(typ) {
+			return reflect.Value{}, fmt.Errorf("%w: converter gave %T, not %s", ErrConvert, v, typ)
+		}
+
+		return rv.Convert(typ), nil
+	}
+
+	v, err := convertKind(typ, arg)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrConvert, err)
+	}
+
+	return v, nil
+}
+
+func convertKind(typ reflect.Type, arg string) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+
+	//nolint:exhaustive // other kinds need Converters
+	switch {
+	case typ == durationType:
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return v, fmt.Errorf("parse duration %q: %w", arg, err)
+		}
+
+		v.SetInt(int64(d))
+	case typ.Kind() == reflect.String:
+		v.SetString(arg)
+	case typ.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(arg)
+		if err != nil {
+			return v, fmt.Errorf("parse bool %q: %w", arg, err)
+		}
+
+		v.SetBool(b)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		i, err := strconv.ParseInt(arg, 0, typ.Bits())
+		if err != nil {
+			return v, fmt.Errorf("parse int %q: %w", arg, err)
+		}
+
+		v.SetInt(i)
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		u, err := strconv.ParseUint(arg, 0, typ.Bits())
+		if err != nil {
+			return v, fmt.Errorf("parse uint %q: %w", arg, err)
+		}
+
+		v.SetUint(u)
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(arg, typ.Bits())
+		if err != nil {
+			return v, fmt.Errorf("parse float %q: %w", arg, err)
+		}
+
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf("no converter for %s", typ)
+	}
+
+	return v, nil
+}
+
+var _ flag.Value = (*convFlag)(nil)
+
+// convFlag is a flag.Value which sets a struct field using a Converter.
+type convFlag struct {
+	t     *Trigger
+	field reflect.Value
+}
+
+func (f *convFlag) String() string {
+	if f == nil || !f.field.IsValid() || f.field.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprint(f.field.Interface())
+}
+
+func (f *convFlag) Set(arg string) error {
+	v, err := f.t.Route.Conv.Convert(f.t, f.field.Type(), arg)
+	if isInternal(err) {
+		// package flag shows this error to the user, so keep the cause in the log
+		f.t.Route.Logger.Error("convert flag", "err", err)
+
+		return errors.New(InternalErrorMsg)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	f.field.Set(v)
+
+	return nil
+}
+
+// fillFlags fills the Trigger's FlagSet from a new value of the given Flags type,
+// returning a func to get the value once parsed.
+//
+// Top-level fields with a Converter are filled using it, and the rest using go-flagsfiller.
+func (t *Trigger) fillFlags(typ reflect.Type) (func() interface{}, error) {
+	flags := reflect.New(typ)
+	get := func() interface{} { return flags.Elem().Interface() }
+
+	conv := []int(nil)
+	fields := []reflect.StructField(nil)
+
+	if typ.Kind() == reflect.Struct {
+		fields = make([]reflect.StructField, typ.NumField())
+
+		for i := range fields {
+			sf := typ.Field(i)
+			fields[i] = reflect.StructField{Name: sf.Name, Type: sf.Type, Tag: sf.Tag}
+
+			_, isConv := t.Route.Conv.Get(sf.Type)
+			if isConv && sf.PkgPath == "" {
+				conv = append(conv, i)
+			}
+
+			if isConv || sf.PkgPath != "" {
+				fields[i] = reflect.StructField{Name: fmt.Sprintf("Skip%d", i), Type: skipType}
+			}
+		}
+	}
+
+	if len(conv) == 0 {
+		err := filler.Fill(t.FlagSet, flags.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("fill flags: %w", err)
+		}
+
+		return get, nil
+	}
+
+	shadow := reflect.New(reflect.StructOf(fields))
+
+	err := filler.Fill(t.FlagSet, shadow.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("fill flags: %w", err)
+	}
+
+	for _, i := range conv {
+		err := t.fillConvFlag(typ.Field(i), flags.Elem().Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("fill flag %s: %w", typ.Field(i).Name, err)
+		}
+	}
+
+	return func() interface{} {
+		for i, sf := range fields {
+			if sf.Type != skipType {
+				flags.Elem().Field(i).Set(shadow.Elem().Field(i))
+			}
+		}
+
+		return get()
+	}, nil
+}
+
+func (t *Trigger) fillConvFlag(sf reflect.StructField, field reflect.Value) error {
+	name, ok := sf.Tag.Lookup("flag")
+	if !ok {
+		name = ff.DefaultFieldRenamer(sf.Name)
+	} else if name == "" {
+		// empty flag override signals to skip this field, like go-flagsfiller
+		return nil
+	}
+
+	fv := &convFlag{t: t, field: field}
+
+	if def, ok := sf.Tag.Lookup("default"); ok {
+		err := fv.Set(def)
+		if err != nil {
+			return fmt.Errorf("default %q: %w", def, err)
+		}
+	}
+
+	t.FlagSet.Var(fv, name, sf.Tag.Get("usage"))
+
+	return nil
+}
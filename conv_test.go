@@ -0,0 +1,71 @@
+package route_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-snart/route"
+)
+
+type testShout string
+
+func TestConvertKinds(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewConvStore()
+
+	for arg, expect := range map[string]interface{}{
+		"foo":  "foo",
+		"true": true,
+		"-12":  int8(-12),
+		"0x10": uint(16),
+		"1.5":  1.5,
+		"2m":   2 * time.Minute,
+	} {
+		v, err := c.Convert(nil, reflect.TypeOf(expect), arg)
+		if err != nil {
+			t.Errorf("convert %q: %s", arg, err)
+
+			continue
+		}
+
+		if got := v.Interface(); got != expect {
+			t.Errorf("convert %q: expect %#v\ngot %#v", arg, expect, got)
+		}
+	}
+
+	_, err := c.Convert(nil, reflect.TypeOf(0), "nope")
+	if !errors.Is(err, route.ErrConvert) {
+		t.Errorf("expect %v\ngot %v", route.ErrConvert, err)
+	}
+}
+
+func TestConvertCustom(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewConvStore()
+	typ := reflect.TypeOf(testShout(""))
+
+	c.Add(typ, func(_ *route.Trigger, arg string) (interface{}, error) {
+		return strings.ToUpper(arg), nil
+	})
+
+	v, err := c.Convert(nil, typ, "hi")
+	if err != nil {
+		t.Fatalf("convert: %s", err)
+	}
+
+	if got := v.Interface(); got != testShout("HI") {
+		t.Errorf("expect %#v\ngot %#v", testShout("HI"), got)
+	}
+
+	c.Del(typ)
+
+	v, _ = c.Convert(nil, typ, "hi")
+	if got := v.Interface(); got != testShout("hi") {
+		t.Errorf("expect %#v\ngot %#v", testShout("hi"), got)
+	}
+}
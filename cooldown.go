@@ -0,0 +1,275 @@
+package route
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/superloach/confy"
+)
+
+// KeyCooldown is the Confy key used to load/store cooldowns.
+const KeyCooldown = "cooldown"
+
+// cooldownPrune is how often Take drops cooldowns that have fully recovered.
+const cooldownPrune = time.Minute
+
+// Scope selects who shares a cooldown.
+type Scope int
+
+const (
+	// ScopeUser gives each user their own cooldown.
+	ScopeUser Scope = iota
+	// ScopeChannel gives each channel its own cooldown.
+	ScopeChannel
+	// ScopeGuild gives each guild its own cooldown. In DMs, each DM channel has its own.
+	ScopeGuild
+	// ScopeGlobal shares one cooldown between everyone.
+	ScopeGlobal
+)
+
+// Cooldown limits a Cmd to being run Uses times Per duration, within a Scope.
+//
+// Uses are regained gradually, like a token bucket: one every Per/Uses.
+type Cooldown struct {
+	Scope Scope
+	Uses  int
+	Per   time.Duration
+}
+
+// CooldownError occurs when a Cmd is run while it is on cooldown.
+type CooldownError struct {
+	Wait time.Duration
+}
+
+func (c *CooldownError) Error() string {
+	return fmt.Sprintf("slow down, try again in %ds", int(math.Ceil(c.Wait.Seconds())))
+}
+
+// Class gets the Class of the CooldownError, which is always ClassUser.
+func (c *CooldownError) Class() Class {
+	return ClassUser
+}
+
+// bucket is the state of a Cooldown for one key.
+type bucket struct {
+	Uses float64   `json:"uses"`
+	Last time.Time `json:"last"`
+	Full time.Time `json:"full"`
+}
+
+// refill gets the bucket as of now, given the Cooldown it belongs to.
+func (b bucket) refill(cd Cooldown, now time.Time) bucket {
+	if b.Last.IsZero() || !now.Before(b.Full) {
+		return bucket{Uses: float64(cd.Uses), Last: now, Full: now}
+	}
+
+	b.Uses = math.Min(float64(cd.Uses), b.Uses+now.Sub(b.Last).Seconds()*cd.rate())
+	b.Last = now
+
+	return b
+}
+
+func (cd Cooldown) rate() float64 {
+	return float64(cd.Uses) / cd.Per.Seconds()
+}
+
+// CooldownStore is a concurrent-safe store of Cooldown states.
+type CooldownStore struct {
+	// Confy is used to persist Cooldowns, if it is set.
+	Confy confy.Confy
+
+	ma     map[string]bucket
+	pruned time.Time
+	mu     sync.Mutex
+}
+
+// NewCooldownStore creates a usable CooldownStore, which isn't persisted.
+func NewCooldownStore() *CooldownStore {
+	return &CooldownStore{
+		Confy: nil,
+
+		ma:     map[string]bucket{},
+		pruned: time.Time{},
+		mu:     sync.Mutex{},
+	}
+}
+
+// OpenCooldownStore creates a usable CooldownStore persisted through the given Confy, and calls Load.
+func OpenCooldownStore(c confy.Confy) (*CooldownStore, error) {
+	cds := NewCooldownStore()
+	cds.Confy = c
+
+	if err := cds.Load(); err != nil {
+		return nil, fmt.Errorf("cds load: %w", err)
+	}
+
+	return cds, nil
+}
+
+// Load updates the CooldownStore with data from the Confy, if it is set.
+func (c *CooldownStore) Load() error {
+	if c.Confy == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	err := confyLoad(c.Confy, KeyCooldown, &c.ma)
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyCooldown, err)
+	}
+
+	return nil
+}
+
+// Store updates the Confy with data from the CooldownStore, if it is set.
+// Cooldowns that have fully recovered are dropped first.
+func (c *CooldownStore) Store() error {
+	if c.Confy == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.prune(time.Now())
+	err := c.Confy.Set(KeyCooldown, c.ma)
+	c.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyCooldown, err)
+	}
+
+	return nil
+}
+
+// Take uses each of the given Cooldowns once, with the state at the matching key.
+//
+// If any Cooldown has no uses left, none are used, and the longest wait until
+// all could be used is returned.
+func (c *CooldownStore) Take(keys []string, cds []Cooldown, now time.Time) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.pruned) >= cooldownPrune {
+		c.prune(now)
+	}
+
+	bs := make([]bucket, len(cds))
+	wait := time.Duration(0)
+
+	for i, cd := range cds {
+		bs[i] = c.ma[keys[i]].refill(cd, now)
+
+		if bs[i].Uses < 1 {
+			w := time.Duration((1 - bs[i].Uses) / cd.rate() * float64(time.Second))
+			if w > wait {
+				wait = w
+			}
+		}
+	}
+
+	if wait > 0 {
+		return wait, false
+	}
+
+	for i, cd := range cds {
+		b := bs[i]
+		b.Uses--
+		b.Full = now.Add(time.Duration((float64(cd.Uses) - b.Uses) / cd.rate() * float64(time.Second)))
+		c.ma[keys[i]] = b
+	}
+
+	return 0, true
+}
+
+// prune drops cooldowns that have fully recovered. c.mu must be held.
+func (c *CooldownStore) prune(now time.Time) {
+	for key, b := range c.ma {
+		if !now.Before(b.Full) {
+			delete(c.ma, key)
+		}
+	}
+
+	c.pruned = now
+}
+
+// Reset forgets the state at the given key.
+func (c *CooldownStore) Reset(key string) {
+	c.mu.Lock()
+	delete(c.ma, key)
+	c.mu.Unlock()
+}
+
+// CooldownKey gets the key for the state of a Cooldown of the Trigger's Cmd.
+//
+// Cooldowns with the same Scope but different limits get different keys.
+func (t *Trigger) CooldownKey(cd Cooldown) string {
+	return t.cooldownKey(t.PathName(), cd)
+}
+
+// cooldownKey gets the key for the state of a Cooldown of the Cmd with the given full name.
+func (t *Trigger) cooldownKey(name string, cd Cooldown) string {
+	key := name + "/" + strconv.Itoa(cd.Uses) + "/" + cd.Per.String() + "/"
+
+	switch cd.Scope {
+	case ScopeUser:
+		return key + "user:" + t.Message.Author.ID.String()
+	case ScopeChannel:
+		return key + "channel:" + t.Message.ChannelID.String()
+	case ScopeGuild:
+		if !t.Message.GuildID.IsValid() {
+			return key + "dm:" + t.Message.ChannelID.String()
+		}
+
+		return key + "guild:" + t.Message.GuildID.String()
+	case ScopeGlobal:
+		fallthrough
+	default:
+		return key + "global"
+	}
+}
+
+// takeCooldowns uses the Cooldowns of the Trigger's Cmd and its parents, or returns a CooldownError.
+//
+// A parent's Cooldowns are kept under its own name, so they are shared by all of its subcommands.
+func (t *Trigger) takeCooldowns() error {
+	path := t.Path
+	if len(path) == 0 {
+		path = []Cmd{t.Command}
+	}
+
+	cds := []Cooldown(nil)
+	keys := []string(nil)
+	name := ""
+
+	for i, cmd := range path {
+		if i > 0 {
+			name += " "
+		}
+
+		name += cmd.Name
+
+		for _, cd := range cmd.Cooldowns {
+			if cd.Uses < 1 || cd.Per <= 0 {
+				continue
+			}
+
+			cds = append(cds, cd)
+			keys = append(keys, t.cooldownKey(name, cd))
+		}
+	}
+
+	if len(cds) == 0 {
+		return nil
+	}
+
+	wait, ok := t.Route.Cooldown.Take(keys, cds, time.Now())
+	if !ok {
+		return &CooldownError{Wait: wait}
+	}
+
+	return nil
+}
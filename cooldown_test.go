@@ -0,0 +1,349 @@
+package route_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+	"github.com/superloach/confy"
+
+	"github.com/go-snart/route"
+)
+
+func TestCooldownTake(t *testing.T) {
+	t.Parallel()
+
+	cds := route.NewCooldownStore()
+	cd := []route.Cooldown{{Scope: route.ScopeUser, Uses: 2, Per: 10 * time.Second}}
+	key := []string{"cmd/user:1"}
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, ok := cds.Take(key, cd, now); !ok {
+			t.Fatalf("expect use %d ok", i)
+		}
+	}
+
+	wait, ok := cds.Take(key, cd, now)
+	if ok {
+		t.Fatal("expect !ok")
+	}
+
+	if wait != 5*time.Second {
+		t.Errorf("expect wait 5s, got %s", wait)
+	}
+
+	if _, ok := cds.Take(key, cd, now.Add(5*time.Second)); !ok {
+		t.Error("expect ok after refill")
+	}
+
+	if _, ok := cds.Take([]string{"cmd/user:2"}, cd, now); !ok {
+		t.Error("expect other key ok")
+	}
+}
+
+func TestCooldownTakeAll(t *testing.T) {
+	t.Parallel()
+
+	cds := route.NewCooldownStore()
+	cd := []route.Cooldown{
+		{Scope: route.ScopeUser, Uses: 1, Per: time.Second},
+		{Scope: route.ScopeGlobal, Uses: 1, Per: time.Minute},
+	}
+	now := time.Unix(1000, 0)
+
+	if _, ok := cds.Take([]string{"cmd/user:1", "cmd/global"}, cd, now); !ok {
+		t.Fatal("expect ok")
+	}
+
+	wait, ok := cds.Take([]string{"cmd/user:2", "cmd/global"}, cd, now)
+	if ok || wait != time.Minute {
+		t.Fatalf("expect !ok with wait 1m, got %t %s", ok, wait)
+	}
+
+	// the user cooldown shouldn't have been used by the failed take
+	if _, ok := cds.Take([]string{"cmd/user:2"}, cd[:1], now); !ok {
+		t.Error("expect user cooldown unused")
+	}
+}
+
+func TestCooldownStore(t *testing.T) {
+	t.Parallel()
+
+	c := confy.NewMem()
+
+	cds, err := route.OpenCooldownStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	cd := []route.Cooldown{{Scope: route.ScopeGlobal, Uses: 1, Per: time.Hour}}
+	key := []string{"cmd/global"}
+
+	if _, ok := cds.Take(key, cd, time.Now()); !ok {
+		t.Fatal("expect ok")
+	}
+
+	err = cds.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	cds2, err := route.OpenCooldownStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if _, ok := cds2.Take(key, cd, time.Now()); ok {
+		t.Error("expect stored cooldown to load")
+	}
+}
+
+func TestCooldownPrune(t *testing.T) {
+	t.Parallel()
+
+	cds := route.NewCooldownStore()
+	cd := []route.Cooldown{{Scope: route.ScopeGlobal, Uses: 1, Per: time.Second}}
+	now := time.Now().Add(time.Hour)
+
+	if _, ok := cds.Take([]string{"old/global"}, cd, now); !ok {
+		t.Fatal("expect ok")
+	}
+
+	if _, ok := cds.Take([]string{"new/global"}, cd, now.Add(2*time.Minute)); !ok {
+		t.Fatal("expect ok")
+	}
+
+	c := confy.NewMem()
+	cds.Confy = c
+
+	err := cds.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	stored := map[string]json.RawMessage{}
+
+	err = c.Get(route.KeyCooldown, &stored)
+	if err != nil {
+		t.Fatalf("get: %s", err)
+	}
+
+	if _, ok := stored["old/global"]; ok {
+		t.Error("expect recovered cooldown to be pruned")
+	}
+
+	if _, ok := stored["new/global"]; !ok {
+		t.Error("expect active cooldown to be kept")
+	}
+}
+
+func TestCooldownKey(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Author: discord.User{ID: 1}, Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	cd := []route.Cooldown{
+		{Scope: route.ScopeUser, Uses: 1, Per: 5 * time.Second},
+		{Scope: route.ScopeUser, Uses: 10, Per: time.Minute},
+	}
+	keys := []string{tr.CooldownKey(cd[0]), tr.CooldownKey(cd[1])}
+
+	if keys[0] == keys[1] {
+		t.Fatalf("expect different keys, got %q", keys[0])
+	}
+
+	cds := route.NewCooldownStore()
+	now := time.Unix(1000, 0)
+
+	if _, ok := cds.Take(keys, cd, now); !ok {
+		t.Fatal("expect ok")
+	}
+
+	if _, ok := cds.Take(keys, cd, now); ok {
+		t.Error("expect !ok")
+	}
+}
+
+func TestCooldownKeyDM(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	cd := route.Cooldown{Scope: route.ScopeGuild, Uses: 1, Per: time.Hour}
+	keys := []string(nil)
+
+	for _, user := range []discord.UserID{1, 2} {
+		const line = "//cmd"
+
+		m := discord.Message{
+			ChannelID: discord.ChannelID(user) + 100,
+			Author:    discord.User{ID: user},
+			Content:   line,
+		}
+
+		tr, err := r.Trigger(testPfx, m, line)
+		if err != nil {
+			t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+		}
+
+		keys = append(keys, tr.CooldownKey(cd))
+	}
+
+	if keys[0] == keys[1] {
+		t.Errorf("expect different keys for different DMs, got %q", keys[0])
+	}
+}
+
+func TestHandleCooldown(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	c := testConfy()
+	r := testRoute(t, s, c)
+
+	runs := 0
+
+	cmd, _ := testCmd()
+	cmd.Cooldowns = []route.Cooldown{{Scope: route.ScopeChannel, Uses: 1, Per: time.Hour}}
+	cmd.Func = func(*route.Trigger) error {
+		runs++
+
+		return nil
+	}
+
+	r.Cmd.Add(cmd)
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	msg := &gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: channel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testMMe.Mention() + " " + cmd.Name,
+		},
+	}
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	r.Handle(msg)
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: (&route.CooldownError{Wait: time.Hour}).Error(),
+		},
+		discord.Message{
+			ChannelID: channel,
+		},
+	)
+	r.Handle(msg)
+
+	if runs != 1 {
+		t.Errorf("expect 1 run, got %d", runs)
+	}
+
+	m.Eval()
+}
+
+func TestHandleCooldownParent(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	runs := 0
+	run := func(*route.Trigger) error {
+		runs++
+
+		return nil
+	}
+
+	r.Cmd.Add(route.Cmd{
+		Name:      "admin",
+		Cooldowns: []route.Cooldown{{Scope: route.ScopeChannel, Uses: 1, Per: time.Hour}},
+		Subs: []route.Cmd{
+			{Name: "ban", Func: run},
+			{Name: "kick", Func: run},
+		},
+	})
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	msg := func(content string) *gateway.MessageCreateEvent {
+		return &gateway.MessageCreateEvent{
+			Message: discord.Message{
+				GuildID:   guild,
+				ChannelID: channel,
+				Author: discord.User{
+					ID: 999,
+				},
+				Content: testMMe.Mention() + " " + content,
+			},
+		}
+	}
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	r.Handle(msg("admin ban"))
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: (&route.CooldownError{Wait: time.Hour}).Error(),
+		},
+		discord.Message{
+			ChannelID: channel,
+		},
+	)
+	r.Handle(msg("admin kick"))
+
+	if runs != 1 {
+		t.Errorf("expect 1 run, got %d", runs)
+	}
+
+	m.Eval()
+}
+
+func TestCooldownErrorClass(t *testing.T) {
+	t.Parallel()
+
+	err := error(&route.CooldownError{Wait: 1500 * time.Millisecond})
+
+	if route.Classify(err) != route.ClassUser {
+		t.Errorf("expect ClassUser")
+	}
+
+	var cerr *route.CooldownError
+	if !errors.As(err, &cerr) || err.Error() != "slow down, try again in 2s" {
+		t.Errorf("unexpected error %q", err)
+	}
+}
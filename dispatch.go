@@ -0,0 +1,107 @@
+package route
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dispatch selects which parts of a message are handled as commands.
+type Dispatch int
+
+const (
+	// DispatchLines handles each line of a message as a separate command.
+	DispatchLines Dispatch = iota
+
+	// DispatchMessage handles a whole message as one command. The first line holds the prefix,
+	// command and arguments, and a code block opened on it may continue onto later lines.
+	// The lines after those are available as Trigger.Body.
+	DispatchMessage
+
+	// DispatchFirstLine handles only the first line of a message as a command.
+	// The lines after it are available as Trigger.Body.
+	DispatchFirstLine
+)
+
+// dispatchLine is a part of a message to be handled as a command.
+type dispatchLine struct {
+	line string
+	body string
+}
+
+// dispatch breaks message content into the parts to be handled as commands.
+func (d Dispatch) dispatch(content string) []dispatchLine {
+	first, body := content, ""
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		first, body = content[:i], content[i+1:]
+	}
+
+	switch d {
+	case DispatchMessage:
+		end := commandEnd(content)
+		if end < len(content) {
+			body = content[end+1:]
+		} else {
+			body = ""
+		}
+
+		return []dispatchLine{{line: content[:end], body: body}}
+	case DispatchFirstLine:
+		return []dispatchLine{{line: first, body: body}}
+	case DispatchLines:
+		fallthrough
+	default:
+		lines := strings.Split(content, "\n")
+		dls := make([]dispatchLine, len(lines))
+
+		for i, line := range lines {
+			dls[i] = dispatchLine{line: line, body: ""}
+		}
+
+		return dls
+	}
+}
+
+// commandEnd finds where the command's line ends in content: at the end of the first line,
+// or of the line where a code block opened on the first line is closed.
+func commandEnd(content string) int {
+	end := lineEnd(content, 0)
+
+	for i := 0; i < end; {
+		r, n := utf8.DecodeRuneInString(content[i:])
+		if unicode.IsSpace(r) {
+			i += n
+
+			continue
+		}
+
+		var err error
+
+		// only code blocks may continue onto later lines
+		if content[i] == '`' {
+			_, n, err = scanCode(content[i:])
+		} else {
+			_, n, err = scanToken(content[i:end])
+		}
+
+		if err != nil {
+			return end
+		}
+
+		i += n
+		if i > end {
+			end = lineEnd(content, i)
+		}
+	}
+
+	return end
+}
+
+// lineEnd finds the index of the first newline in s from i, or len(s) if there is none.
+func lineEnd(s string, i int) int {
+	if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+		return i + j
+	}
+
+	return len(s)
+}
@@ -0,0 +1,96 @@
+package route_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+type testDispatched struct {
+	args []string
+	body string
+}
+
+func testDispatch(t *testing.T, d route.Dispatch, content string) []testDispatched {
+	t.Helper()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+	r.Dispatch = d
+
+	runs := []testDispatched(nil)
+
+	cmd, _ := testCmd()
+	cmd.Func = func(t *route.Trigger) error {
+		runs = append(runs, testDispatched{t.Args, t.Body})
+
+		return nil
+	}
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID: guild,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: content,
+		},
+	})
+
+	m.Eval()
+
+	return runs
+}
+
+const testDispatchContent = "//cmd a ```\nb c\n```\n//cmd d"
+
+func TestDispatchLines(t *testing.T) {
+	t.Parallel()
+
+	runs := testDispatch(t, route.DispatchLines, "//cmd a\nchat\n//cmd b")
+	expect := []testDispatched{{[]string{"a"}, ""}, {[]string{"b"}, ""}}
+
+	if !reflect.DeepEqual(runs, expect) {
+		t.Errorf("expect %#v\ngot %#v", expect, runs)
+	}
+}
+
+func TestDispatchMessage(t *testing.T) {
+	t.Parallel()
+
+	runs := testDispatch(t, route.DispatchMessage, testDispatchContent)
+	expect := []testDispatched{{[]string{"a", "b c"}, "//cmd d"}}
+
+	if !reflect.DeepEqual(runs, expect) {
+		t.Errorf("expect %#v\ngot %#v", expect, runs)
+	}
+
+	runs = testDispatch(t, route.DispatchMessage, "//cmd a\n'tis \"quoted\n```\nb")
+	expect = []testDispatched{{[]string{"a"}, "'tis \"quoted\n```\nb"}}
+
+	if !reflect.DeepEqual(runs, expect) {
+		t.Errorf("expect %#v\ngot %#v", expect, runs)
+	}
+}
+
+func TestDispatchFirstLine(t *testing.T) {
+	t.Parallel()
+
+	runs := testDispatch(t, route.DispatchFirstLine, "//cmd a\n//cmd b\nc")
+	expect := []testDispatched{{[]string{"a"}, "//cmd b\nc"}}
+
+	if !reflect.DeepEqual(runs, expect) {
+		t.Errorf("expect %#v\ngot %#v", expect, runs)
+	}
+}
@@ -0,0 +1,360 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+var (
+	// ErrNoState occurs when a Converter needs the Route's State, but there isn't one.
+	ErrNoState = errors.New("no state")
+
+	// ErrGuildOnly occurs when a Converter needs a Guild, but the Trigger isn't in one.
+	ErrGuildOnly = errors.New("only works in a server")
+)
+
+// LookupError occurs when a Converter can't look up entities, because the State failed.
+//
+// It's ClassInternal, so its message isn't shown to users.
+type LookupError struct {
+	What string
+	Err  error
+}
+
+func (l *LookupError) Error() string {
+	return "get " + l.What + ": " + l.Err.Error()
+}
+
+// Unwrap gets the error from the State.
+func (l *LookupError) Unwrap() error {
+	return l.Err
+}
+
+// Class gets the Class of the LookupError, which is always ClassInternal.
+func (l *LookupError) Class() Class {
+	return ClassInternal
+}
+
+// isInternal reports whether an error from a Converter is the bot's fault, rather than the argument's.
+func isInternal(err error) bool {
+	var c Classifier
+
+	return errors.Is(err, ErrNoState) || (errors.As(err, &c) && c.Class() == ClassInternal)
+}
+
+// addEntities stores Converters for Discord entities.
+//
+// Mentions and IDs are accepted for every entity, and names are accepted within a Guild.
+func (c *ConvStore) addEntities() {
+	c.Add(reflect.TypeOf(discord.UserID(0)), convUserID)
+	c.Add(reflect.TypeOf(discord.User{}), convUser)
+	c.Add(reflect.TypeOf(discord.Member{}), convMember)
+	c.Add(reflect.TypeOf(discord.ChannelID(0)), convChannelID)
+	c.Add(reflect.TypeOf(discord.Channel{}), convChannel)
+	c.Add(reflect.TypeOf(discord.RoleID(0)), convRoleID)
+	c.Add(reflect.TypeOf(discord.Role{}), convRole)
+	c.Add(reflect.TypeOf(discord.Emoji{}), convEmoji)
+}
+
+// parseID parses a raw ID, or a mention made with one of the given sigils, like "<@!123>".
+func parseID(arg string, sigils ...string) (discord.Snowflake, bool) {
+	for _, sigil := range sigils {
+		if strings.HasPrefix(arg, "<"+sigil) && strings.HasSuffix(arg, ">") {
+			arg = arg[len(sigil)+1 : len(arg)-1]
+
+			break
+		}
+	}
+
+	id, err := discord.ParseSnowflake(arg)
+	if err != nil || !id.IsValid() {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// findName finds the single entity with a name matching arg, case-insensitively.
+// Exact matches are preferred over prefix matches.
+func findName(kind, arg string, n int, names func(i int) []string) (int, error) {
+	arg = strings.ToLower(arg)
+	exact, prefix := []int(nil), []int(nil)
+
+	for i := 0; i < n; i++ {
+		isExact, isPrefix := false, false
+
+		for _, name := range names(i) {
+			name = strings.ToLower(name)
+
+			isExact = isExact || name == arg
+			isPrefix = isPrefix || (name != "" && strings.HasPrefix(name, arg))
+		}
+
+		switch {
+		case isExact:
+			exact = append(exact, i)
+		case isPrefix:
+			prefix = append(prefix, i)
+		}
+	}
+
+	if len(exact) == 0 {
+		exact = prefix
+	}
+
+	switch len(exact) {
+	case 0:
+		return 0, fmt.Errorf("no %s matching %q", kind, arg)
+	case 1:
+		return exact[0], nil
+	default:
+		return 0, fmt.Errorf("%q matches %d %ss, be more specific", arg, len(exact), kind)
+	}
+}
+
+func convUserID(t *Trigger, arg string) (interface{}, error) {
+	if id, ok := parseID(arg, "@!", "@"); ok {
+		return discord.UserID(id), nil
+	}
+
+	u, err := convUser(t, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.(discord.User).ID, nil
+}
+
+func convUser(t *Trigger, arg string) (interface{}, error) {
+	if t.Route.State == nil {
+		return nil, ErrNoState
+	}
+
+	if id, ok := parseID(arg, "@!", "@"); ok {
+		if t.Message.GuildID.IsValid() {
+			if m, err := t.Route.State.Member(t.Message.GuildID, discord.UserID(id)); err == nil {
+				return m.User, nil
+			}
+		}
+
+		u, err := t.Route.State.User(discord.UserID(id))
+		if err != nil {
+			return nil, fmt.Errorf("no user with id %s", id)
+		}
+
+		return *u, nil
+	}
+
+	m, err := convMember(t, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.(discord.Member).User, nil
+}
+
+func convMember(t *Trigger, arg string) (interface{}, error) {
+	if t.Route.State == nil {
+		return nil, ErrNoState
+	}
+
+	if !t.Message.GuildID.IsValid() {
+		return nil, ErrGuildOnly
+	}
+
+	if id, ok := parseID(arg, "@!", "@"); ok {
+		m, err := t.Route.State.Member(t.Message.GuildID, discord.UserID(id))
+		if err != nil {
+			return nil, fmt.Errorf("no member with id %s", id)
+		}
+
+		return *m, nil
+	}
+
+	ms, err := t.Route.State.Members(t.Message.GuildID)
+	if err != nil {
+		return nil, &LookupError{What: "members", Err: err}
+	}
+
+	i, err := findName("member", strings.TrimPrefix(arg, "@"), len(ms), func(i int) []string {
+		return []string{ms[i].Nick, ms[i].User.Username, ms[i].User.Username + "#" + ms[i].User.Discriminator}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ms[i], nil
+}
+
+func convChannelID(t *Trigger, arg string) (interface{}, error) {
+	if id, ok := parseID(arg, "#"); ok {
+		return discord.ChannelID(id), nil
+	}
+
+	ch, err := convChannel(t, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return ch.(discord.Channel).ID, nil
+}
+
+func convChannel(t *Trigger, arg string) (interface{}, error) {
+	if t.Route.State == nil {
+		return nil, ErrNoState
+	}
+
+	if id, ok := parseID(arg, "#"); ok {
+		ch, err := t.Route.State.Channel(discord.ChannelID(id))
+		if err != nil {
+			return nil, fmt.Errorf("no channel with id %s", id)
+		}
+
+		return *ch, nil
+	}
+
+	if !t.Message.GuildID.IsValid() {
+		return nil, ErrGuildOnly
+	}
+
+	chs, err := t.Route.State.Channels(t.Message.GuildID)
+	if err != nil {
+		return nil, &LookupError{What: "channels", Err: err}
+	}
+
+	i, err := findName("channel", strings.TrimPrefix(arg, "#"), len(chs), func(i int) []string {
+		return []string{chs[i].Name}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chs[i], nil
+}
+
+func convRoleID(t *Trigger, arg string) (interface{}, error) {
+	if id, ok := parseID(arg, "@&"); ok {
+		return discord.RoleID(id), nil
+	}
+
+	r, err := convRole(t, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.(discord.Role).ID, nil
+}
+
+func convRole(t *Trigger, arg string) (interface{}, error) {
+	if t.Route.State == nil {
+		return nil, ErrNoState
+	}
+
+	if !t.Message.GuildID.IsValid() {
+		return nil, ErrGuildOnly
+	}
+
+	if id, ok := parseID(arg, "@&"); ok {
+		r, err := t.Route.State.Role(t.Message.GuildID, discord.RoleID(id))
+		if err != nil {
+			return nil, fmt.Errorf("no role with id %s", id)
+		}
+
+		return *r, nil
+	}
+
+	rs, err := t.Route.State.Roles(t.Message.GuildID)
+	if err != nil {
+		return nil, &LookupError{What: "roles", Err: err}
+	}
+
+	i, err := findName("role", strings.TrimPrefix(arg, "@"), len(rs), func(i int) []string {
+		return []string{rs[i].Name}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rs[i], nil
+}
+
+func convEmoji(t *Trigger, arg string) (interface{}, error) {
+	if e, ok := parseCustomEmoji(arg); ok {
+		return e, nil
+	}
+
+	if isUnicodeEmoji(arg) {
+		//nolint:exhaustivestruct // unicode emoji only have names
+		return discord.Emoji{Name: arg}, nil
+	}
+
+	if t.Route.State == nil {
+		return nil, ErrNoState
+	}
+
+	if !t.Message.GuildID.IsValid() {
+		return nil, ErrGuildOnly
+	}
+
+	if id, ok := parseID(arg); ok {
+		e, err := t.Route.State.Emoji(t.Message.GuildID, discord.EmojiID(id))
+		if err != nil {
+			return nil, fmt.Errorf("no emoji with id %s", id)
+		}
+
+		return *e, nil
+	}
+
+	es, err := t.Route.State.Emojis(t.Message.GuildID)
+	if err != nil {
+		return nil, &LookupError{What: "emojis", Err: err}
+	}
+
+	i, err := findName("emoji", strings.Trim(arg, ":"), len(es), func(i int) []string {
+		return []string{es[i].Name}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return es[i], nil
+}
+
+// parseCustomEmoji parses a custom emoji as sent in messages, like "<:name:123>" or "<a:name:123>".
+func parseCustomEmoji(arg string) (discord.Emoji, bool) {
+	if !strings.HasPrefix(arg, "<") || !strings.HasSuffix(arg, ">") {
+		return discord.Emoji{}, false
+	}
+
+	parts := strings.Split(arg[1:len(arg)-1], ":")
+	if len(parts) != 3 || (parts[0] != "" && parts[0] != "a") || parts[1] == "" {
+		return discord.Emoji{}, false
+	}
+
+	id, ok := parseID(parts[2])
+	if !ok {
+		return discord.Emoji{}, false
+	}
+
+	//nolint:exhaustivestruct // only what the mention tells us
+	return discord.Emoji{
+		ID:       discord.EmojiID(id),
+		Name:     parts[1],
+		Animated: parts[0] == "a",
+	}, true
+}
+
+func isUnicodeEmoji(arg string) bool {
+	for _, r := range arg {
+		if r < unicode.MaxASCII || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return arg != ""
+}
@@ -0,0 +1,166 @@
+package route_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+type testEntityPos struct {
+	User    discord.UserID    `pos:"user"`
+	Channel discord.ChannelID `pos:"channel"`
+	Role    discord.RoleID    `pos:"role"`
+	Emoji   discord.Emoji     `pos:"emoji"`
+}
+
+type testEntityFlags struct {
+	Target discord.UserID `usage:"who to target"`
+	Count  int            `default:"1"`
+}
+
+func TestConvertEntityIDs(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Pos = testEntityPos{}
+	cmd.Flags = testEntityFlags{}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd -target=<@!42> -count=2 <@123> <#456> 789 <a:dance:1011>"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	pos := tr.Pos.(testEntityPos)
+	expect := testEntityPos{
+		User:    123,
+		Channel: 456,
+		Role:    789,
+		Emoji:   discord.Emoji{ID: 1011, Name: "dance", Animated: true},
+	}
+
+	if pos.User != expect.User || pos.Channel != expect.Channel || pos.Role != expect.Role ||
+		pos.Emoji.ID != expect.Emoji.ID || pos.Emoji.Name != expect.Emoji.Name || !pos.Emoji.Animated {
+		t.Errorf("expect %#v\ngot %#v", expect, pos)
+	}
+
+	flags := tr.Flags.(testEntityFlags)
+	if flags.Target != 42 || flags.Count != 2 {
+		t.Errorf("expect %#v\ngot %#v", testEntityFlags{Target: 42, Count: 2}, flags)
+	}
+}
+
+func TestConvertEntityNoState(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Pos = testEntityPos{}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd someone <#456> 789 <:e:1>"
+
+	_, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if !errors.Is(err, route.ErrNoState) {
+		t.Errorf("expect %v\ngot %v", route.ErrNoState, err)
+	}
+
+	if c := route.Classify(err); c != route.ClassInternal {
+		t.Errorf("expect class %s, got %s", route.ClassInternal, c)
+	}
+
+}
+
+func TestConvertLookupError(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Pos = struct {
+		Role discord.Role `pos:"role"`
+	}{}
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	m.Error(http.MethodGet, "/guilds/123/roles", httputil.HTTPError{Status: 500})
+
+	const line = "//cmd mods"
+
+	_, err := r.Trigger(testPfx, discord.Message{GuildID: guild, Content: line}, line)
+
+	var l *route.LookupError
+	if !errors.As(err, &l) {
+		t.Fatalf("expect *route.LookupError\ngot %v", err)
+	}
+
+	if c := route.Classify(err); c != route.ClassInternal {
+		t.Errorf("expect class %s, got %s", route.ClassInternal, c)
+	}
+
+	if msg := route.UserMessage(err); msg != route.InternalErrorMsg {
+		t.Errorf("expect message %q\ngot %q", route.InternalErrorMsg, msg)
+	}
+
+	m.Eval()
+}
+
+func TestConvertChannelName(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Pos = struct {
+		Channel discord.Channel `pos:"channel"`
+	}{}
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	chs := []discord.Channel{
+		{ID: 1, GuildID: guild, Name: "general"},
+		{ID: 2, GuildID: guild, Name: "bot-spam"},
+		{ID: 3, GuildID: guild, Name: "bot-dev"},
+	}
+
+	m.Channels(guild, chs)
+
+	line := "//cmd #GEN"
+
+	tr, err := r.Trigger(testPfx, discord.Message{GuildID: guild, Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	if ch := tr.Pos.(struct {
+		Channel discord.Channel `pos:"channel"`
+	}).Channel; ch.ID != 1 {
+		t.Errorf("expect channel %d, got %d", 1, ch.ID)
+	}
+
+	m.Channels(guild, chs)
+
+	line = "//cmd bot"
+
+	_, err = r.Trigger(testPfx, discord.Message{GuildID: guild, Content: line}, line)
+	if !errors.Is(err, route.ErrArgs) {
+		t.Errorf("expect ambiguous %v\ngot %v", route.ErrArgs, err)
+	}
+
+	m.Eval()
+}
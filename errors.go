@@ -0,0 +1,116 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+// InternalErrorMsg is shown to users instead of the message of an internal error.
+const InternalErrorMsg = "something went wrong running that command"
+
+// ErrorHandler handles an error from handling a line, after it has been logged.
+// The Trigger is nil if the line had no prefix, and may be partially filled otherwise.
+type ErrorHandler = func(t *Trigger, class Class, err error)
+
+// UserError is an error with a message that is safe to show to the invoking user verbatim.
+type UserError struct {
+	Msg string
+	Err error
+}
+
+// NewUserError makes a UserError with a formatted message, and no wrapped error.
+func NewUserError(format string, args ...interface{}) *UserError {
+	return &UserError{
+		Msg: fmt.Sprintf(format, args...),
+		Err: nil,
+	}
+}
+
+func (u *UserError) Error() string {
+	if u.Err == nil {
+		return u.Msg
+	}
+
+	return u.Msg + ": " + u.Err.Error()
+}
+
+// Unwrap gets the wrapped error, which is never shown to the user.
+func (u *UserError) Unwrap() error {
+	return u.Err
+}
+
+// Class gets the Class of the UserError, which is always ClassUser.
+func (u *UserError) Class() Class {
+	return ClassUser
+}
+
+// UserMessage gets a message describing an error that is safe to show to the invoking user.
+//
+// A UserError gives its Msg, and other Classifiers their whole message. Errors from this package
+// give the message they were created with, without the context added by wrapping them.
+// Anything else, including ClassInternal errors, gives InternalErrorMsg.
+func UserMessage(err error) string {
+	var u *UserError
+	if errors.As(err, &u) {
+		return u.Msg
+	}
+
+	var c Classifier
+	if errors.As(err, &c) && c.Class() != ClassInternal {
+		return c.Error()
+	}
+
+	if errors.Is(err, ErrCmdTimeout) {
+		return ErrCmdTimeout.Error()
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		for _, s := range userErrs {
+			if e == s || errors.Unwrap(e) == s {
+				if !strings.HasPrefix(e.Error(), s.Error()) {
+					return s.Error()
+				}
+
+				return e.Error()
+			}
+		}
+	}
+
+	return InternalErrorMsg
+}
+
+// DefaultErrorHandler replies to the Trigger with an embed holding the UserMessage of the error.
+//
+// Nothing is sent for ClassIgnore errors, unknown commands (see Trigger.Suggest),
+// or FlagErrors (which show usage themselves).
+func DefaultErrorHandler(t *Trigger, class Class, err error) {
+	if t == nil || class == ClassIgnore || errors.Is(err, ErrCmdNotFound) {
+		return
+	}
+
+	var f *FlagError
+	if errors.As(err, &f) {
+		return
+	}
+
+	msg := InternalErrorMsg
+	if class == ClassUser {
+		msg = UserMessage(err)
+	}
+
+	rep := t.Reply()
+
+	//nolint:exhaustivestruct // discord types are excessive
+	rep.Embed = &discord.Embed{
+		Title:       "error",
+		Description: msg,
+	}
+
+	err = rep.Send()
+	if err != nil {
+		t.Route.Logger.Warn("error handler", "err", err)
+	}
+}
@@ -0,0 +1,112 @@
+package route_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+func TestUserMessage(t *testing.T) {
+	t.Parallel()
+
+	for err, expect := range map[error]string{
+		io.EOF: route.InternalErrorMsg,
+		&route.UserError{Msg: "nope", Err: io.EOF}:                                                    "nope",
+		fmt.Errorf("run: %w", route.NewUserError("no %d", 1)):                                         "no 1",
+		fmt.Errorf("get: %w", fmt.Errorf("pos: %w", fmt.Errorf("%w: missing <user>", route.ErrArgs))): "bad arguments: missing <user>",
+		fmt.Errorf("run: %w: %s", route.ErrCmdTimeout, io.EOF):                                        "command timed out",
+	} {
+		if got := route.UserMessage(err); got != expect {
+			t.Errorf("user message %q: expect %q\ngot %q", err, expect, got)
+		}
+	}
+}
+
+func TestHandleUserError(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Func = func(*route.Trigger) error {
+		return &route.UserError{Msg: "you can't do that", Err: io.EOF}
+	}
+	r.Cmd.Add(cmd)
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: "you can't do that",
+		},
+		discord.Message{
+			ChannelID: channel,
+		},
+	)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: channel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testPfx.Value + cmd.Name,
+		},
+	})
+
+	m.Eval()
+}
+
+func TestHandleErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	got := []route.Class(nil)
+	r.ErrorHandler = func(t *route.Trigger, class route.Class, err error) {
+		got = append(got, class)
+	}
+
+	cmd, _ := testCmd()
+	cmd.Func = func(*route.Trigger) error {
+		return io.EOF
+	}
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID: guild,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "chat\n//cmd\n//nope",
+		},
+	})
+
+	expect := []route.Class{route.ClassIgnore, route.ClassInternal, route.ClassUser}
+	if fmt.Sprint(got) != fmt.Sprint(expect) {
+		t.Errorf("expect %v\ngot %v", expect, got)
+	}
+
+	m.Eval()
+}
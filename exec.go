@@ -0,0 +1,242 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/gateway"
+)
+
+// ErrShutdown occurs when submitting to an Executor that has been shut down.
+var ErrShutdown = errors.New("executor shut down")
+
+// Order selects which jobs an Executor keeps in order.
+type Order int
+
+const (
+	// OrderChannel runs lines from the same channel one at a time, in order.
+	OrderChannel Order = iota
+
+	// OrderUser runs lines from the same user one at a time, in order.
+	OrderUser
+
+	// OrderNone runs lines in any order, as workers are free.
+	OrderNone
+)
+
+// ExecStats is a snapshot of the state of an Executor.
+type ExecStats struct {
+	// Queued is the number of jobs waiting to run.
+	Queued int
+	// Running is the number of jobs running.
+	Running int
+	// Done is the number of jobs that have finished.
+	Done uint64
+	// Wait is the total time finished and running jobs spent queued.
+	Wait time.Duration
+	// MaxWait is the longest time a job spent queued.
+	MaxWait time.Duration
+}
+
+type execJob struct {
+	f    func()
+	enq  time.Time
+	next *execJob
+}
+
+// execKey holds the queued jobs for one key, which are run one at a time.
+type execKey struct {
+	name       string
+	head, tail *execJob
+}
+
+// Executor runs jobs on a fixed pool of workers, keeping jobs with the same key in order.
+type Executor struct {
+	Order Order
+
+	limit  int
+	keys   map[string]*execKey
+	ready  []*execKey
+	stats  ExecStats
+	seq    uint64
+	closed bool
+
+	mu      sync.Mutex
+	work    *sync.Cond
+	notFull *sync.Cond
+	wg      sync.WaitGroup
+}
+
+// NewExecutor creates an Executor with the given number of workers, and starts them.
+//
+// At most limit jobs may be queued; Submit blocks until there is room.
+// A limit less than 1 means no limit.
+func NewExecutor(workers, limit int, order Order) *Executor {
+	if workers < 1 {
+		workers = 1
+	}
+
+	//nolint:exhaustivestruct // conds are set up below
+	e := &Executor{
+		Order: order,
+
+		limit: limit,
+		keys:  map[string]*execKey{},
+	}
+
+	e.work = sync.NewCond(&e.mu)
+	e.notFull = sync.NewCond(&e.mu)
+
+	e.wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go e.worker()
+	}
+
+	return e
+}
+
+// key gets the key used to order jobs for the given message.
+func (e *Executor) key(m *gateway.MessageCreateEvent) string {
+	switch e.Order {
+	case OrderChannel:
+		return "channel:" + m.ChannelID.String()
+	case OrderUser:
+		return "user:" + m.Author.ID.String()
+	case OrderNone:
+		fallthrough
+	default:
+		return ""
+	}
+}
+
+// Submit queues a job to run after any other queued jobs with the same key.
+// An empty key is never kept in order.
+//
+// If the queue is full, Submit blocks until there is room.
+func (e *Executor) Submit(key string, f func()) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for !e.closed && e.limit > 0 && e.stats.Queued >= e.limit {
+		e.notFull.Wait()
+	}
+
+	if e.closed {
+		return ErrShutdown
+	}
+
+	if key == "" {
+		e.seq++
+		key = "seq:" + strconv.FormatUint(e.seq, 10)
+	}
+
+	j := &execJob{f: f, enq: time.Now(), next: nil}
+
+	k, ok := e.keys[key]
+	if !ok {
+		k = &execKey{name: key, head: nil, tail: nil}
+		e.keys[key] = k
+		e.ready = append(e.ready, k)
+		e.work.Signal()
+	}
+
+	if k.tail == nil {
+		k.head = j
+	} else {
+		k.tail.next = j
+	}
+
+	k.tail = j
+	e.stats.Queued++
+
+	return nil
+}
+
+func (e *Executor) worker() {
+	defer e.wg.Done()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for {
+		for len(e.ready) == 0 && !e.closed {
+			e.work.Wait()
+		}
+
+		if len(e.ready) == 0 {
+			return
+		}
+
+		k := e.ready[0]
+		e.ready = e.ready[1:]
+
+		j := k.head
+		k.head = j.next
+
+		if k.head == nil {
+			k.tail = nil
+		}
+
+		wait := time.Since(j.enq)
+		e.stats.Queued--
+		e.stats.Running++
+		e.stats.Wait += wait
+
+		if wait > e.stats.MaxWait {
+			e.stats.MaxWait = wait
+		}
+
+		e.notFull.Signal()
+		e.mu.Unlock()
+
+		j.f()
+
+		e.mu.Lock()
+		e.stats.Running--
+		e.stats.Done++
+
+		if k.head == nil {
+			delete(e.keys, k.name)
+		} else {
+			e.ready = append(e.ready, k)
+			e.work.Signal()
+		}
+	}
+}
+
+// Stats gets a snapshot of the state of the Executor.
+func (e *Executor) Stats() ExecStats {
+	e.mu.Lock()
+	stats := e.stats
+	e.mu.Unlock()
+
+	return stats
+}
+
+// Shutdown stops accepting jobs, and waits for queued and running jobs to finish,
+// or for the Context to be done.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	e.closed = true
+	e.work.Broadcast()
+	e.notFull.Broadcast()
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,188 @@
+package route_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+func TestExecutorOrder(t *testing.T) {
+	t.Parallel()
+
+	e := route.NewExecutor(4, 0, route.OrderChannel)
+
+	mu := sync.Mutex{}
+	got := map[string][]int{}
+
+	for i := 0; i < 50; i++ {
+		for _, key := range []string{"a", "b", "c"} {
+			i, key := i, key
+
+			err := e.Submit(key, func() {
+				mu.Lock()
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+			if err != nil {
+				t.Fatalf("submit: %s", err)
+			}
+		}
+	}
+
+	err := e.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("shutdown: %s", err)
+	}
+
+	for key, is := range got {
+		for i := range is {
+			if is[i] != i {
+				t.Fatalf("key %s: out of order: %v", key, is)
+			}
+		}
+	}
+
+	if stats := e.Stats(); stats.Done != 150 || stats.Queued != 0 || stats.Running != 0 {
+		t.Errorf("expect 150 done, got %#v", stats)
+	}
+
+	if err := e.Submit("a", func() {}); !errors.Is(err, route.ErrShutdown) {
+		t.Errorf("expect %v\ngot %v", route.ErrShutdown, err)
+	}
+}
+
+func TestExecutorConcurrent(t *testing.T) {
+	t.Parallel()
+
+	e := route.NewExecutor(2, 0, route.OrderChannel)
+	block := make(chan struct{})
+	done := make(chan struct{})
+
+	_ = e.Submit("a", func() { <-block })
+	_ = e.Submit("b", func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("key b blocked by key a")
+	}
+
+	close(block)
+
+	_ = e.Shutdown(context.Background())
+}
+
+func TestExecutorBackPressure(t *testing.T) {
+	t.Parallel()
+
+	e := route.NewExecutor(1, 1, route.OrderNone)
+	block := make(chan struct{})
+	submitted := make(chan struct{})
+
+	_ = e.Submit("", func() { <-block })
+
+	// wait for the worker to take the first job
+	for e.Stats().Running == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_ = e.Submit("", func() {})
+
+	go func() {
+		_ = e.Submit("", func() {})
+		close(submitted)
+	}()
+
+	select {
+	case <-submitted:
+		t.Errorf("expect submit to block on a full queue")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if stats := e.Stats(); stats.Queued != 1 || stats.Running != 1 {
+		t.Errorf("expect 1 queued and 1 running, got %#v", stats)
+	}
+
+	close(block)
+	<-submitted
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := e.Shutdown(ctx)
+	if err != nil {
+		t.Errorf("shutdown: %s", err)
+	}
+}
+
+func TestExecutorShutdownTimeout(t *testing.T) {
+	t.Parallel()
+
+	e := route.NewExecutor(1, 0, route.OrderNone)
+	block := make(chan struct{})
+
+	defer close(block)
+
+	_ = e.Submit("", func() { <-block })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := e.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect %v\ngot %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestHandleExecutor(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+	r.Executor = route.NewExecutor(2, 10, route.OrderChannel)
+
+	got := []string(nil)
+
+	cmd, _ := testCmd()
+	cmd.Func = func(t *route.Trigger) error {
+		got = append(got, t.Args[0])
+
+		return nil
+	}
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID: guild,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "//cmd 1\n//cmd 2\n//cmd 3",
+		},
+	})
+
+	err := r.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("shutdown: %s", err)
+	}
+
+	if fmt.Sprint(got) != "[1 2 3]" {
+		t.Errorf("expect %v\ngot %v", []string{"1", "2", "3"}, got)
+	}
+
+	m.Eval()
+}
@@ -10,6 +10,7 @@ require (
 	github.com/kr/text v0.2.0 // indirect
 	github.com/mavolin/dismock/v2 v2.0.0
 	github.com/superloach/confy v0.7.1
+	golang.org/x/text v0.3.5
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
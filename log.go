@@ -0,0 +1,73 @@
+package route
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is a structured logger, which *slog.Logger satisfies.
+//
+// Args are alternating keys and values, like "guild", 123.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+var _ Logger = StdLogger{}
+
+// StdLogger is a Logger that writes lines like "error: msg key=value" using package log.
+type StdLogger struct {
+	// Log is written to, or the standard logger if it is nil.
+	Log *log.Logger
+
+	// Verbose enables Debug messages, which are discarded otherwise.
+	Verbose bool
+}
+
+// Debug logs at the debug level, if Verbose is set.
+func (s StdLogger) Debug(msg string, args ...interface{}) {
+	if s.Verbose {
+		s.print("debug", msg, args)
+	}
+}
+
+// Info logs at the info level.
+func (s StdLogger) Info(msg string, args ...interface{}) {
+	s.print("info", msg, args)
+}
+
+// Warn logs at the warn level.
+func (s StdLogger) Warn(msg string, args ...interface{}) {
+	s.print("warn", msg, args)
+}
+
+// Error logs at the error level.
+func (s StdLogger) Error(msg string, args ...interface{}) {
+	s.print("error", msg, args)
+}
+
+func (s StdLogger) print(level, msg string, args []interface{}) {
+	b := &strings.Builder{}
+	b.WriteString(level + ": " + msg)
+
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(b, " %v", args[i])
+
+			break
+		}
+
+		fmt.Fprintf(b, " %v=%q", args[i], fmt.Sprint(args[i+1]))
+	}
+
+	if s.Log == nil {
+		log.Print(b.String())
+
+		return
+	}
+
+	s.Log.Print(b.String())
+}
@@ -0,0 +1,115 @@
+package route_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+type testLogEntry struct {
+	level string
+	msg   string
+	args  map[string]interface{}
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []testLogEntry
+}
+
+func (l *testLogger) log(level, msg string, args []interface{}) {
+	e := testLogEntry{level, msg, map[string]interface{}{}}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		e.args[args[i].(string)] = args[i+1]
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, e)
+	l.mu.Unlock()
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+func (l *testLogger) Error(msg string, args ...interface{}) { l.log("error", msg, args) }
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	l := route.StdLogger{Log: log.New(buf, "", 0), Verbose: false}
+
+	l.Debug("hidden")
+	l.Error("handle line", "guild", 123, "line", "a b")
+
+	const expect = "error: handle line guild=\"123\" line=\"a b\"\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("expect %q\ngot %q", expect, got)
+	}
+}
+
+func TestHandleLogClass(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+	l := &testLogger{}
+	r.Logger = l
+	r.ErrorHandler = nil
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: channel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "chat\n//yeet\n//cmd \"open",
+		},
+	})
+
+	m.Eval()
+
+	expect := []string{"debug", "info", "info"}
+	if len(l.entries) != len(expect) {
+		t.Fatalf("expect %d entries, got %#v", len(expect), l.entries)
+	}
+
+	for i, e := range l.entries {
+		if e.level != expect[i] {
+			t.Errorf("entry %d: expect level %s, got %s", i, expect[i], e.level)
+		}
+
+		if e.args["guild"] != discord.GuildID(guild) || e.args["channel"] != discord.ChannelID(channel) {
+			t.Errorf("entry %d: expect guild and channel, got %v", i, e.args)
+		}
+	}
+
+	if cmd := l.entries[1].args["command"]; cmd != "yeet" {
+		t.Errorf("expect command %q, got %v", "yeet", cmd)
+	}
+
+	if line := l.entries[2].args["line"]; !strings.HasPrefix(line.(string), "//cmd") {
+		t.Errorf("expect line, got %v", line)
+	}
+}
@@ -0,0 +1,94 @@
+package route
+
+import (
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+// mentionPrefix finds a mention of the bot at the start of the line, in any of the forms
+// "<@id>", "<@!id>", or "<@&role>" for the bot's managed role.
+func mentionPrefix(line string, me discord.User, role discord.RoleID) (string, bool) {
+	if !strings.HasPrefix(line, "<@") {
+		return "", false
+	}
+
+	end := strings.IndexByte(line, '>')
+	if end < 0 {
+		return "", false
+	}
+
+	mention := line[:end+1]
+
+	if strings.HasPrefix(mention, "<@&") {
+		id, ok := parseID(mention, "@&")
+
+		return mention, ok && role.IsValid() && discord.RoleID(id) == role
+	}
+
+	id, ok := parseID(mention, "@!", "@")
+
+	return mention, ok && discord.UserID(id) == me.ID
+}
+
+// managedRole finds the role managed by Discord for the bot in the given Guild.
+func (r *Route) managedRole(g discord.GuildID, mme *discord.Member) discord.RoleID {
+	if mme == nil || len(mme.RoleIDs) == 0 {
+		return 0
+	}
+
+	roles, err := r.State.Roles(g)
+	if err != nil {
+		r.Logger.Warn("get roles", "err", err, "guild", g)
+
+		return 0
+	}
+
+	for _, role := range roles {
+		if role.Managed && hasRole(mme.RoleIDs, role.ID) {
+			return role.ID
+		}
+	}
+
+	return 0
+}
+
+// MentionHelp replies to a Trigger made with only a mention of the bot,
+// with the prefixes usable in the Guild and a hint to get help.
+//
+// Prefixes are shown by their Clean form. Regex prefixes without one aren't shown.
+func (t *Trigger) MentionHelp() error {
+	shown := []string(nil)
+
+	for _, rule := range t.Route.Prefix.rulesFor(t.Message.GuildID) {
+		if rule.empty() || (rule.Regex && rule.Clean == "") {
+			continue
+		}
+
+		shown = append(shown, "`"+rule.prefix(rule.Value).Clean+"`")
+	}
+
+	rep := t.Reply()
+
+	switch len(shown) {
+	case 0:
+		rep.Content = "i don't have a prefix here, so mention me instead."
+	case 1:
+		rep.Content = "my prefix here is " + shown[0] + "."
+	default:
+		rep.Content = "my prefixes here are " + strings.Join(shown, ", ") + "."
+	}
+
+	hint := t.Prefix.Clean
+	if len(shown) > 0 {
+		hint = strings.Trim(shown[0], "`")
+	}
+
+	if _, ok := t.Route.Cmd.Get("help"); ok {
+		rep.Content += " try `" + hint + "help` for a list of commands."
+	} else {
+		rep.Content += " try `" + hint + "<command> -help` for help with a command."
+	}
+
+	return rep.Send()
+}
@@ -0,0 +1,237 @@
+package route_test
+
+import (
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+const (
+	testMentionGuild   = 123
+	testMentionChannel = 456
+	testMentionRole    = 789
+)
+
+func TestForLineMentionForms(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	o := route.Origin{Guild: testMentionGuild, Role: testMentionRole}
+
+	tests := map[string]string{
+		"<@1234567890> cmd":  "<@1234567890>",
+		"<@!1234567890> cmd": "<@!1234567890>",
+		"<@&789> cmd":        "<@&789>",
+		"<@&790> cmd":        "",
+		"<@1> cmd":           "",
+		"<@!1234567890 cmd":  "",
+		"<#1234567890> cmd":  "",
+	}
+
+	for line, expect := range tests {
+		pfx, ok := r.Prefix.ForLineAt(o, testMe, &testMMeNick, line)
+		if ok != (expect != "") || pfx.Value != expect || pfx.Mention != ok {
+			t.Errorf("%q: expect %q, got %#v (%t)", line, expect, pfx, ok)
+		}
+
+		if ok && pfx.Clean != "@"+testMMeNick.Nick+" " {
+			t.Errorf("%q: expect nick clean, got %q", line, pfx.Clean)
+		}
+	}
+}
+
+func TestForLineNoMention(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	r.Prefix.SetChannel(testMentionChannel, "")
+
+	o := route.Origin{Guild: testMentionGuild, Channel: testMentionChannel, NoMention: true}
+
+	pfx, ok := r.Prefix.ForLineAt(o, testMe, nil, testMe.Mention()+" cmd")
+	if !ok || pfx.Mention || pfx.Value != "" {
+		t.Errorf("expect empty prefix, got %#v (%t)", pfx, ok)
+	}
+}
+
+func TestHandleRoleMention(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, run := testCmd()
+	r.Cmd.Add(cmd)
+
+	mme := testMMe
+	mme.RoleIDs = []discord.RoleID{1, testMentionRole}
+
+	m.Me(testMe)
+	m.Member(testMentionGuild, mme)
+	m.Roles(testMentionGuild, []discord.Role{
+		{ID: 1},
+		{ID: testMentionRole, Managed: true},
+	})
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testMentionGuild,
+			ChannelID: testMentionChannel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "<@&789> " + cmd.Name + " -run=foo",
+		},
+	})
+
+	if *run != "foo" {
+		t.Errorf("expect %q, got %q", "foo", *run)
+	}
+
+	m.Eval()
+}
+
+func TestHandleMentionDisabled(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, run := testCmd()
+	r.Cmd.Add(cmd)
+
+	r.Conf.Set(testMentionGuild, route.GuildConf{NoMention: true})
+
+	m.Me(testMe)
+	m.Member(testMentionGuild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testMentionGuild,
+			ChannelID: testMentionChannel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testMMe.Mention() + " " + cmd.Name + " -run=foo",
+		},
+	})
+
+	if *run != "" {
+		t.Errorf("expect no run, got %q", *run)
+	}
+
+	m.Eval()
+}
+
+func TestMentionHelp(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	r.Prefix.Set(testMentionGuild, "!", "?")
+	r.Cmd.Add(route.Cmd{Name: "help", Func: func(*route.Trigger) error { return nil }})
+
+	m.Me(testMe)
+	m.Member(testMentionGuild, testMMe)
+	m.SendMessage(nil, discord.Message{
+		ChannelID: testMentionChannel,
+		Content:   "my prefixes here are `!`, `?`. try `!help` for a list of commands.",
+	})
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testMentionGuild,
+			ChannelID: testMentionChannel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "<@1234567890>",
+		},
+	})
+
+	m.Eval()
+}
+
+func TestMentionHelpClean(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	err := r.Prefix.SetRules(testMentionGuild,
+		route.PrefixRule{Value: `[!?]`, Regex: true, Clean: "!"},
+		route.PrefixRule{Value: `b+`, Regex: true},
+		route.PrefixRule{Value: "bot ", Space: true},
+	)
+	if err != nil {
+		t.Fatalf("set rules: %s", err)
+	}
+
+	m.Me(testMe)
+	m.Member(testMentionGuild, testMMe)
+	m.SendMessage(nil, discord.Message{
+		ChannelID: testMentionChannel,
+		Content:   "my prefixes here are `!`, `bot `. try `!<command> -help` for help with a command.",
+	})
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testMentionGuild,
+			ChannelID: testMentionChannel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "<@1234567890>",
+		},
+	})
+
+	m.Eval()
+}
+
+func TestMentionGroupUsage(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testTreeCmd()
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	m.Member(testMentionGuild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "`prefix` usage",
+			Description: "manage prefixes",
+			Fields: []discord.EmbedField{
+				{
+					Name:   "flag `-global`",
+					Value:  "apply globally\ndefault: `false`",
+					Inline: false,
+				},
+				{
+					Name:   "subcommand `set`",
+					Value:  "set the prefix",
+					Inline: false,
+				},
+			},
+		},
+		discord.Message{
+			ChannelID: testMentionChannel,
+			Content:   "",
+		},
+	)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   testMentionGuild,
+			ChannelID: testMentionChannel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: "<@1234567890> prefix",
+		},
+	})
+
+	m.Eval()
+}
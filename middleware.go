@@ -0,0 +1,52 @@
+package route
+
+// Middleware wraps a Func, returning a Func that usually calls the wrapped one.
+type Middleware = func(Func) Func
+
+// Use adds Middleware that wraps every Cmd run by the Route.
+func (r *Route) Use(mw ...Middleware) {
+	r.mwMu.Lock()
+	r.mw = append(r.mw, mw...)
+	r.mwMu.Unlock()
+}
+
+// UseCat adds Middleware that wraps every Cmd in the given category.
+func (r *Route) UseCat(cat string, mw ...Middleware) {
+	r.mwMu.Lock()
+	r.catMW[cat] = append(r.catMW[cat], mw...)
+	r.mwMu.Unlock()
+}
+
+// Chain wraps the Func of the last Cmd in a path, like Trigger.Path, with all applicable Middleware.
+//
+// Route Middleware is outermost, followed by category Middleware for each Cat in the path,
+// then the Middleware of each Cmd in the path, from the top-level Cmd down.
+// Within each group, Middleware added first runs first.
+func (r *Route) Chain(path []Cmd) Func {
+	if len(path) == 0 {
+		return nil
+	}
+
+	r.mwMu.RLock()
+	mws := append([]Middleware(nil), r.mw...)
+	cats := map[string]bool{}
+
+	for _, cmd := range path {
+		if !cats[cmd.Cat] {
+			cats[cmd.Cat] = true
+			mws = append(mws, r.catMW[cmd.Cat]...)
+		}
+	}
+	r.mwMu.RUnlock()
+
+	for _, cmd := range path {
+		mws = append(mws, cmd.Middleware...)
+	}
+
+	f := path[len(path)-1].Func
+	for i := len(mws) - 1; i >= 0; i-- {
+		f = mws[i](f)
+	}
+
+	return f
+}
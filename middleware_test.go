@@ -0,0 +1,136 @@
+package route_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/go-snart/route"
+)
+
+func testMiddleware(order *[]string, name string) route.Middleware {
+	return func(next route.Func) route.Func {
+		return func(t *route.Trigger) error {
+			*order = append(*order, name)
+
+			return next(t)
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	order := []string(nil)
+
+	r.Use(testMiddleware(&order, "route1"), testMiddleware(&order, "route2"))
+	r.UseCat(testCat, testMiddleware(&order, "cat"))
+	r.UseCat("other", testMiddleware(&order, "other"))
+
+	cmd, _ := testCmd()
+	cmd.Middleware = []route.Middleware{testMiddleware(&order, "cmd")}
+	cmd.Func = func(*route.Trigger) error {
+		order = append(order, "func")
+
+		return nil
+	}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if err != nil {
+		t.Errorf("run: %s", err)
+	}
+
+	expect := []string{"route1", "route2", "cat", "cmd", "func"}
+	if len(order) != len(expect) {
+		t.Fatalf("expect %v\ngot %v", expect, order)
+	}
+
+	for i := range expect {
+		if order[i] != expect[i] {
+			t.Errorf("expect %v\ngot %v", expect, order)
+
+			break
+		}
+	}
+}
+
+func TestChainShortCircuit(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	r.Use(func(route.Func) route.Func {
+		return func(*route.Trigger) error {
+			return nil
+		}
+	})
+
+	cmd, run := testCmd()
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd -run=foo"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if err != nil {
+		t.Errorf("run: %s", err)
+	}
+
+	if *run != "" {
+		t.Errorf("expect %q\ngot %q", "", *run)
+	}
+}
+
+func TestChainSubcommand(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	order := []string(nil)
+
+	r.Use(testMiddleware(&order, "route"))
+
+	r.Cmd.Add(route.Cmd{
+		Name:       "admin",
+		Middleware: []route.Middleware{testMiddleware(&order, "admin")},
+		Subs: []route.Cmd{{
+			Name:       "ban",
+			Middleware: []route.Middleware{testMiddleware(&order, "ban")},
+			Func: func(*route.Trigger) error {
+				order = append(order, "func")
+
+				return nil
+			},
+		}},
+	})
+
+	const line = "//admin ban"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if err != nil {
+		t.Errorf("run: %s", err)
+	}
+
+	expect := []string{"route", "admin", "ban", "func"}
+	if !reflect.DeepEqual(order, expect) {
+		t.Errorf("expect %v\ngot %v", expect, order)
+	}
+}
@@ -0,0 +1,237 @@
+package route
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	ff "github.com/itzg/go-flagsfiller"
+)
+
+// Parser selects how the flags of a Cmd are parsed.
+type Parser int
+
+const (
+	// ParserDefault uses the Parser of the Route, which defaults to ParserStd.
+	ParserDefault Parser = iota
+
+	// ParserStd parses flags like package flag: flags stop at the first positional argument.
+	ParserStd
+
+	// ParserGNU parses flags like GNU getopt_long: flags may come between positional arguments,
+	// "--" ends flags, "--name=value" and "--name value" set long flags, and short flags
+	// (declared with a `short` struct tag) may be bundled like "-abc".
+	//
+	// Flags stop at the first positional argument of a Cmd with Subs, so that subcommands keep their own.
+	ParserGNU
+)
+
+// Parser gets the Parser used for the Trigger's Cmd.
+func (t *Trigger) Parser() Parser {
+	if t.Command.Parser != ParserDefault {
+		return t.Command.Parser
+	}
+
+	if t.Route.Parser != ParserDefault {
+		return t.Route.Parser
+	}
+
+	return ParserStd
+}
+
+// fillShorts registers short aliases for flags with a `short` struct tag.
+func (t *Trigger) fillShorts(typ reflect.Type) error {
+	t.shorts = map[string]string{}
+
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+
+		short, ok := sf.Tag.Lookup("short")
+		if !ok {
+			continue
+		}
+
+		name, ok := sf.Tag.Lookup("flag")
+		if !ok {
+			name = ff.DefaultFieldRenamer(sf.Name)
+		}
+
+		f := t.FlagSet.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("short %q: no flag %q", short, name)
+		}
+
+		if len([]rune(short)) != 1 || t.FlagSet.Lookup(short) != nil {
+			return fmt.Errorf("short %q for %q: must be a single unused character", short, name)
+		}
+
+		t.FlagSet.Var(f.Value, short, f.Usage)
+		t.shorts[name] = short
+	}
+
+	return nil
+}
+
+// parseFlags parses the given arguments into the Trigger's FlagSet, returning positional arguments.
+func (t *Trigger) parseFlags(args []string) ([]string, error) {
+	if t.Parser() != ParserGNU {
+		err := t.FlagSet.Parse(args)
+
+		return t.FlagSet.Args(), err
+	}
+
+	p := gnuParser{
+		fs:           t.FlagSet,
+		interspersed: len(t.Command.Subs) == 0,
+		args:         args,
+		rest:         nil,
+	}
+
+	err := p.parse()
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(t.FlagSet.Output(), err)
+		}
+
+		t.FlagSet.Usage()
+
+		return nil, err
+	}
+
+	return p.rest, nil
+}
+
+type gnuParser struct {
+	fs           *flag.FlagSet
+	interspersed bool
+	args         []string
+	rest         []string
+}
+
+func (p *gnuParser) parse() error {
+	for len(p.args) > 0 {
+		arg := p.args[0]
+		p.args = p.args[1:]
+
+		switch {
+		case arg == "--":
+			p.rest = append(p.rest, p.args...)
+
+			return nil
+		case strings.HasPrefix(arg, "--"):
+			err := p.long(arg[2:])
+			if err != nil {
+				return err
+			}
+		case len(arg) > 1 && arg[0] == '-' && !p.isNumber(arg[1:]):
+			err := p.short(arg[1:])
+			if err != nil {
+				return err
+			}
+		case p.interspersed:
+			p.rest = append(p.rest, arg)
+		default:
+			p.rest = append(p.rest, arg)
+			p.rest = append(p.rest, p.args...)
+
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// isNumber reports whether a "-" argument is a negative number, rather than flags.
+func (p *gnuParser) isNumber(s string) bool {
+	if p.fs.Lookup(s[:1]) != nil {
+		return false
+	}
+
+	return strings.Trim(s, "0123456789.") == ""
+}
+
+func (p *gnuParser) long(s string) error {
+	name, val, hasVal := s, "", false
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		name, val, hasVal = s[:i], s[i+1:], true
+	}
+
+	return p.set("--", name, val, hasVal)
+}
+
+func (p *gnuParser) short(s string) error {
+	name := s
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		name = s[:i]
+	}
+
+	// single-dash long flags, for compatibility with ParserStd
+	if len([]rune(name)) > 1 && p.fs.Lookup(name) != nil {
+		return p.long(s)
+	}
+
+	for i, r := range s {
+		name := string(r)
+
+		f := p.fs.Lookup(name)
+		if f != nil && !isBoolFlag(f) {
+			val := strings.TrimPrefix(s[i+len(name):], "=")
+
+			return p.set("-", name, val, val != "")
+		}
+
+		// a value for a bool flag, like "-v=false" or "-fv=false"
+		if rest := s[i+len(name):]; strings.HasPrefix(rest, "=") {
+			return p.set("-", name, rest[1:], true)
+		}
+
+		err := p.set("-", name, "", false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *gnuParser) set(dash, name, val string, hasVal bool) error {
+	f := p.fs.Lookup(name)
+	if f == nil {
+		if name == "h" || name == "help" {
+			return flag.ErrHelp
+		}
+
+		return fmt.Errorf("flag provided but not defined: %s%s", dash, name)
+	}
+
+	if !hasVal {
+		if isBoolFlag(f) {
+			val = "true"
+		} else {
+			if len(p.args) == 0 {
+				return fmt.Errorf("flag needs an argument: %s%s", dash, name)
+			}
+
+			val, p.args = p.args[0], p.args[1:]
+		}
+	}
+
+	err := p.fs.Set(name, val)
+	if err != nil {
+		return fmt.Errorf("invalid value %q for flag %s%s: %w", val, dash, name, err)
+	}
+
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,127 @@
+package route_test
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/state"
+	"github.com/diamondburned/arikawa/v2/utils/httputil"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+type testGNUFlags struct {
+	Reason  string `short:"r" usage:"why"`
+	Force   bool   `short:"f"`
+	Verbose bool   `short:"v"`
+	Count   int    `short:"n"`
+}
+
+func testGNURoute(t *testing.T, s *state.State) *route.Route {
+	t.Helper()
+
+	r := testRoute(t, s, testConfy())
+	r.Parser = route.ParserGNU
+
+	cmd, _ := testCmd()
+	cmd.Flags = testGNUFlags{}
+	r.Cmd.Add(cmd)
+
+	return r
+}
+
+func TestParseGNU(t *testing.T) {
+	t.Parallel()
+
+	r := testGNURoute(t, nil)
+
+	for line, expect := range map[string]testGNUFlags{
+		"//cmd @user -reason spam":        {Reason: "spam"},
+		"//cmd @user --reason=spam":       {Reason: "spam"},
+		"//cmd --reason spam @user":       {Reason: "spam"},
+		"//cmd -fv @user -n3":             {Force: true, Verbose: true, Count: 3},
+		"//cmd -fr spam @user":            {Force: true, Reason: "spam"},
+		"//cmd -vn 3 @user -- --reason=x": {Verbose: true, Count: 3},
+		"//cmd -v=false -f=true @user":    {Force: true},
+		"//cmd -fv=false @user":           {Force: true},
+	} {
+		tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if err != nil {
+			t.Errorf("trigger %q %q: %s", testPfx.Clean, line, err)
+
+			continue
+		}
+
+		if got := tr.Flags.(testGNUFlags); got != expect {
+			t.Errorf("trigger %q: expect %#v\ngot %#v", line, expect, got)
+		}
+
+		if tr.Args[0] != "@user" {
+			t.Errorf("trigger %q: expect first arg %q, got %v", line, "@user", tr.Args)
+		}
+	}
+
+	const line = "//cmd @user -- --reason=x -5"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	expect := []string{"@user", "--reason=x", "-5"}
+	if !reflect.DeepEqual(tr.Args, expect) {
+		t.Errorf("expect %v\ngot %v", expect, tr.Args)
+	}
+}
+
+func TestParseGNUErr(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	r := testGNURoute(t, s)
+
+	const channel = 1234567890
+
+	for _, line := range []string{
+		"//cmd --nope",
+		"//cmd -fx",
+		"//cmd --reason",
+		"//cmd -n three",
+	} {
+		// parse errors reply with usage
+		m.Error(http.MethodPost, "/channels/1234567890/messages", httputil.HTTPError{Status: 500})
+
+		_, err := r.Trigger(testPfx, discord.Message{ChannelID: channel, Content: line}, line)
+		if err == nil || errors.Is(err, flag.ErrHelp) {
+			t.Errorf("trigger %q: expect parse error, got %v", line, err)
+		}
+	}
+
+	m.Eval()
+}
+
+func TestParseStdShort(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Flags = testGNUFlags{}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd -r spam @user -f"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	if got := tr.Flags.(testGNUFlags); got.Reason != "spam" || got.Force {
+		t.Errorf("expect reason %q and flags to stop at @user, got %#v", "spam", got)
+	}
+}
@@ -0,0 +1,215 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+var (
+	// ErrDMOnly occurs when a DM-only Cmd is run in a guild.
+	ErrDMOnly = errors.New("only works in DMs")
+
+	// ErrNSFWOnly occurs when an NSFW-only Cmd is run outside of an NSFW channel.
+	ErrNSFWOnly = errors.New("only works in NSFW channels")
+
+	// ErrOwnerOnly occurs when an owner-only Cmd is run by someone not in Route.Owners.
+	ErrOwnerOnly = errors.New("only works for bot owners")
+)
+
+//nolint:gochecknoglobals // fixed lookup table
+var permNames = []struct {
+	perm discord.Permissions
+	name string
+}{
+	{discord.PermissionAdministrator, "Administrator"},
+	{discord.PermissionCreateInstantInvite, "Create Invite"},
+	{discord.PermissionKickMembers, "Kick Members"},
+	{discord.PermissionBanMembers, "Ban Members"},
+	{discord.PermissionManageChannels, "Manage Channels"},
+	{discord.PermissionManageGuild, "Manage Server"},
+	{discord.PermissionAddReactions, "Add Reactions"},
+	{discord.PermissionViewAuditLog, "View Audit Log"},
+	{discord.PermissionPrioritySpeaker, "Priority Speaker"},
+	{discord.PermissionStream, "Video"},
+	{discord.PermissionViewChannel, "View Channel"},
+	{discord.PermissionSendMessages, "Send Messages"},
+	{discord.PermissionSendTTSMessages, "Send TTS Messages"},
+	{discord.PermissionManageMessages, "Manage Messages"},
+	{discord.PermissionEmbedLinks, "Embed Links"},
+	{discord.PermissionAttachFiles, "Attach Files"},
+	{discord.PermissionReadMessageHistory, "Read Message History"},
+	{discord.PermissionMentionEveryone, "Mention Everyone"},
+	{discord.PermissionUseExternalEmojis, "Use External Emojis"},
+	{discord.PermissionConnect, "Connect"},
+	{discord.PermissionSpeak, "Speak"},
+	{discord.PermissionMuteMembers, "Mute Members"},
+	{discord.PermissionDeafenMembers, "Deafen Members"},
+	{discord.PermissionMoveMembers, "Move Members"},
+	{discord.PermissionUseVAD, "Use Voice Activity"},
+	{discord.PermissionChangeNickname, "Change Nickname"},
+	{discord.PermissionManageNicknames, "Manage Nicknames"},
+	{discord.PermissionManageRoles, "Manage Roles"},
+	{discord.PermissionManageWebhooks, "Manage Webhooks"},
+	{discord.PermissionManageEmojis, "Manage Emojis"},
+}
+
+// PermNames gets the names of the given Permissions, as shown in the Discord client.
+func PermNames(perms discord.Permissions) []string {
+	names := []string(nil)
+
+	for _, pn := range permNames {
+		if perms.Has(pn.perm) {
+			names = append(names, pn.name)
+			perms &^= pn.perm
+		}
+	}
+
+	if perms != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint64(perms)))
+	}
+
+	return names
+}
+
+// PermError occurs when the invoker, or the bot if Bot is true, is missing Permissions for a Cmd.
+type PermError struct {
+	Bot     bool
+	Missing discord.Permissions
+}
+
+func (p *PermError) Error() string {
+	who := "you need"
+	if p.Bot {
+		who = "i need"
+	}
+
+	return who + " permissions: " + strings.Join(PermNames(p.Missing), ", ")
+}
+
+// Class gets the Class of the PermError, which is always ClassUser.
+func (p *PermError) Class() Class {
+	return ClassUser
+}
+
+// Requires gets the requirements of the Cmd to be run, for display.
+func (cmd Cmd) Requires() []string {
+	reqs := []string(nil)
+
+	if cmd.OwnerOnly {
+		reqs = append(reqs, "bot owners only")
+	}
+
+	if cmd.GuildOnly {
+		reqs = append(reqs, "servers only")
+	}
+
+	if cmd.DMOnly {
+		reqs = append(reqs, "DMs only")
+	}
+
+	if cmd.NSFW {
+		reqs = append(reqs, "NSFW channels only")
+	}
+
+	if cmd.Perms != 0 {
+		reqs = append(reqs, "you need: "+strings.Join(PermNames(cmd.Perms), ", "))
+	}
+
+	if cmd.BotPerms != 0 {
+		reqs = append(reqs, "bot needs: "+strings.Join(PermNames(cmd.BotPerms), ", "))
+	}
+
+	return reqs
+}
+
+// guard checks that the Trigger meets the requirements of every Cmd in its Path.
+func (t *Trigger) guard() error {
+	var req Cmd
+
+	for _, cmd := range t.Path {
+		req.OwnerOnly = req.OwnerOnly || cmd.OwnerOnly
+		req.GuildOnly = req.GuildOnly || cmd.GuildOnly
+		req.DMOnly = req.DMOnly || cmd.DMOnly
+		req.NSFW = req.NSFW || cmd.NSFW
+		req.Perms |= cmd.Perms
+		req.BotPerms |= cmd.BotPerms
+	}
+
+	if req.OwnerOnly && !t.Route.IsOwner(t.Message.Author.ID) {
+		return ErrOwnerOnly
+	}
+
+	guild := t.Message.GuildID.IsValid()
+
+	if req.DMOnly && guild {
+		return ErrDMOnly
+	}
+
+	if !guild {
+		// DMs have no permissions, and are never NSFW-gated
+		if req.GuildOnly || req.Perms != 0 || req.BotPerms != 0 {
+			return ErrGuildOnly
+		}
+
+		return nil
+	}
+
+	if req.NSFW {
+		ch, err := t.Route.State.Channel(t.Message.ChannelID)
+		if err != nil {
+			return fmt.Errorf("get channel: %w", err)
+		}
+
+		if !ch.NSFW {
+			return ErrNSFWOnly
+		}
+	}
+
+	if req.Perms != 0 {
+		err := t.checkPerms(t.Message.Author.ID, req.Perms, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.BotPerms != 0 {
+		me, err := t.Route.State.Me()
+		if err != nil {
+			return fmt.Errorf("get me: %w", err)
+		}
+
+		err = t.checkPerms(me.ID, req.BotPerms, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Trigger) checkPerms(user discord.UserID, need discord.Permissions, bot bool) error {
+	have, err := t.Route.State.Permissions(t.Message.ChannelID, user)
+	if err != nil {
+		return fmt.Errorf("get permissions: %w", err)
+	}
+
+	if missing := need &^ have; missing != 0 {
+		return &PermError{Bot: bot, Missing: missing}
+	}
+
+	return nil
+}
+
+// IsOwner checks whether the given user is one of the Route's Owners.
+func (r *Route) IsOwner(id discord.UserID) bool {
+	for _, owner := range r.Owners {
+		if owner == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,229 @@
+package route_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+const (
+	testPermGuild   = 123
+	testPermChannel = 456
+	testPermUser    = 999
+)
+
+func testPermMsg(guild discord.GuildID) *gateway.MessageCreateEvent {
+	return &gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: testPermChannel,
+			Author: discord.User{
+				ID: testPermUser,
+			},
+			Content: testPfx.Value + testName,
+		},
+	}
+}
+
+func testPermGuildData(owner discord.UserID, everyone discord.Permissions) discord.Guild {
+	return discord.Guild{
+		ID:      testPermGuild,
+		OwnerID: owner,
+		Roles: []discord.Role{{
+			ID:          testPermGuild,
+			Permissions: everyone,
+		}},
+	}
+}
+
+func testPermError(m *dismock.Mocker, msg string) {
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: msg,
+		},
+		discord.Message{
+			ChannelID: testPermChannel,
+		},
+	)
+}
+
+func TestPermNames(t *testing.T) {
+	t.Parallel()
+
+	names := route.PermNames(discord.PermissionBanMembers | discord.PermissionKickMembers | 1<<40)
+	expect := []string{"Kick Members", "Ban Members", "0x10000000000"}
+
+	if !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect %q, got %q", expect, names)
+	}
+}
+
+func TestPermError(t *testing.T) {
+	t.Parallel()
+
+	err := &route.PermError{Bot: true, Missing: discord.PermissionEmbedLinks}
+	if err.Error() != "i need permissions: Embed Links" {
+		t.Errorf("unexpected message %q", err)
+	}
+
+	if route.Classify(err) != route.ClassUser {
+		t.Errorf("expect ClassUser")
+	}
+}
+
+func TestCmdRequires(t *testing.T) {
+	t.Parallel()
+
+	cmd := route.Cmd{
+		GuildOnly: true,
+		Perms:     discord.PermissionManageMessages,
+		BotPerms:  discord.PermissionManageMessages | discord.PermissionEmbedLinks,
+	}
+
+	reqs := cmd.Requires()
+	expect := []string{
+		"servers only",
+		"you need: Manage Messages",
+		"bot needs: Manage Messages, Embed Links",
+	}
+
+	if !reflect.DeepEqual(reqs, expect) {
+		t.Errorf("expect %q, got %q", expect, reqs)
+	}
+}
+
+func TestHandleOwnerOnly(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+	r.Owners = []discord.UserID{1}
+
+	cmd, _ := testCmd()
+	cmd.OwnerOnly = true
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	testPermError(m, route.ErrOwnerOnly.Error())
+
+	r.Handle(testPermMsg(0))
+
+	m.Eval()
+}
+
+func TestHandleGuildOnly(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Perms = discord.PermissionKickMembers
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	testPermError(m, route.ErrGuildOnly.Error())
+
+	r.Handle(testPermMsg(0))
+
+	m.Eval()
+}
+
+func TestHandleDMOnly(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.DMOnly = true
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	m.Member(testPermGuild, testMMe)
+	testPermError(m, route.ErrDMOnly.Error())
+
+	r.Handle(testPermMsg(testPermGuild))
+
+	m.Eval()
+}
+
+func TestHandleNSFW(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.NSFW = true
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	m.Member(testPermGuild, testMMe)
+	m.Channel(discord.Channel{ID: testPermChannel, GuildID: testPermGuild, NSFW: false})
+	testPermError(m, route.ErrNSFWOnly.Error())
+
+	r.Handle(testPermMsg(testPermGuild))
+
+	m.Eval()
+}
+
+func TestHandlePerms(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Perms = discord.PermissionKickMembers | discord.PermissionSendMessages | discord.PermissionBanMembers
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	m.Member(testPermGuild, testMMe)
+	m.Channel(discord.Channel{ID: testPermChannel, GuildID: testPermGuild})
+	m.Guild(testPermGuildData(1, discord.PermissionSendMessages))
+	m.Member(testPermGuild, discord.Member{User: discord.User{ID: testPermUser}})
+	testPermError(m, "you need permissions: Kick Members, Ban Members")
+
+	r.Handle(testPermMsg(testPermGuild))
+
+	m.Eval()
+}
+
+func TestHandleBotPerms(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	r := testRoute(t, s, testConfy())
+
+	run := ""
+
+	cmd, _ := testCmd()
+	cmd.Func = testFunc(&run)
+	cmd.BotPerms = discord.PermissionEmbedLinks
+	r.Cmd.Add(cmd)
+
+	m.Me(testMe)
+	m.Member(testPermGuild, testMMe)
+	m.Me(testMe)
+	m.Channel(discord.Channel{
+		ID:      testPermChannel,
+		GuildID: testPermGuild,
+		Permissions: []discord.Overwrite{{
+			ID:   testPermGuild,
+			Type: discord.OverwriteRole,
+			Deny: discord.PermissionEmbedLinks,
+		}},
+	})
+	m.Guild(testPermGuildData(1, discord.PermissionEmbedLinks))
+	m.Member(testPermGuild, testMMe)
+	testPermError(m, "i need permissions: Embed Links")
+
+	r.Handle(testPermMsg(testPermGuild))
+
+	if run != "" {
+		t.Errorf("expect no run, got %q", run)
+	}
+
+	m.Eval()
+}
@@ -0,0 +1,187 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrArgs occurs when positional arguments don't fit the Pos of a Cmd.
+var ErrArgs = errors.New("bad arguments")
+
+// posField describes a field of a Cmd's Pos struct.
+//
+// Fields are tagged like `pos:"name"`, `pos:"name,optional"` or `pos:"name,rest"`.
+// A rest field must be a slice, and comes last. Untagged fields are ignored.
+type posField struct {
+	index    int
+	name     string
+	optional bool
+	rest     bool
+}
+
+func posFields(typ reflect.Type) ([]posField, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("pos must be a struct, not %s", typ)
+	}
+
+	fields := []posField(nil)
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+
+		tag, ok := sf.Tag.Lookup("pos")
+		if !ok {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		f := posField{
+			index:    i,
+			name:     opts[0],
+			optional: false,
+			rest:     false,
+		}
+
+		if f.name == "" {
+			f.name = strings.ToLower(sf.Name)
+		}
+
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "optional":
+				f.optional = true
+			case "rest":
+				f.rest = true
+			default:
+				return nil, fmt.Errorf("field %s: unknown pos option %q", sf.Name, opt)
+			}
+		}
+
+		if f.rest && sf.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("field %s: rest must be a slice, not %s", sf.Name, sf.Type)
+		}
+
+		if n := len(fields); n > 0 {
+			prev := fields[n-1]
+
+			if prev.rest {
+				return nil, fmt.Errorf("field %s: rest field %q must come last", sf.Name, prev.name)
+			}
+
+			if prev.optional && !f.optional && !f.rest {
+				return nil, fmt.Errorf("field %s: required after optional %q", sf.Name, prev.name)
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// Synopsis gets a short description of how to invoke the Cmd, like "cmd <user> [count] [reason...]".
+//
+// Subcommands are shown as "cmd <sub>" when the Cmd can't be run on its own.
+func (cmd Cmd) Synopsis() string {
+	syn := cmd.Name
+
+	if cmd.Func == nil && len(cmd.Subs) > 0 {
+		return syn + " <sub>"
+	}
+
+	if cmd.Pos == nil {
+		return syn
+	}
+
+	fields, err := posFields(reflect.TypeOf(cmd.Pos))
+	if err != nil {
+		return syn
+	}
+
+	for _, f := range fields {
+		switch {
+		case f.rest:
+			syn += " [" + f.name + "...]"
+		case f.optional:
+			syn += " [" + f.name + "]"
+		default:
+			syn += " <" + f.name + ">"
+		}
+	}
+
+	return syn
+}
+
+// fillPos converts the Trigger's Args into a new value of the Cmd's Pos.
+func (t *Trigger) fillPos() error {
+	typ := reflect.TypeOf(t.Command.Pos)
+
+	fields, err := posFields(typ)
+	if err != nil {
+		return fmt.Errorf("pos fields: %w", err)
+	}
+
+	pos := reflect.New(typ).Elem()
+	args := t.Args
+
+	for _, f := range fields {
+		fv := pos.Field(f.index)
+
+		if f.rest {
+			rest := reflect.MakeSlice(fv.Type(), 0, len(args))
+
+			for _, arg := range args {
+				v, err := t.Route.Conv.Convert(t, fv.Type().Elem(), arg)
+				if err != nil {
+					return posError(f.name, err)
+				}
+
+				rest = reflect.Append(rest, v)
+			}
+
+			fv.Set(rest)
+
+			args = nil
+
+			break
+		}
+
+		if len(args) == 0 {
+			if f.optional {
+				continue
+			}
+
+			return fmt.Errorf("%w: missing <%s>", ErrArgs, f.name)
+		}
+
+		v, err := t.Route.Conv.Convert(t, fv.Type(), args[0])
+		if err != nil {
+			return posError(f.name, err)
+		}
+
+		fv.Set(v)
+
+		args = args[1:]
+	}
+
+	if len(args) > 0 {
+		return fmt.Errorf("%w: too many arguments, starting at %q", ErrArgs, args[0])
+	}
+
+	t.Pos = pos.Interface()
+
+	return nil
+}
+
+// posError wraps an error from converting the named argument.
+//
+// Internal errors keep their Class, so their messages aren't shown to users.
+func posError(name string, err error) error {
+	if isInternal(err) {
+		return fmt.Errorf("<%s>: %w", name, err)
+	}
+
+	return fmt.Errorf("%w: <%s>: %s", ErrArgs, name, err)
+}
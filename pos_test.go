@@ -0,0 +1,97 @@
+package route_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/go-snart/route"
+)
+
+type testPos struct {
+	User   string   `pos:"user"`
+	Count  int      `pos:"count,optional"`
+	Reason []string `pos:"reason,rest"`
+	Other  bool
+}
+
+func testPosCmd() route.Cmd {
+	cmd, _ := testCmd()
+	cmd.Pos = testPos{}
+
+	return cmd
+}
+
+func TestSynopsis(t *testing.T) {
+	t.Parallel()
+
+	const expect = testName + " <user> [count] [reason...]"
+
+	if syn := testPosCmd().Synopsis(); syn != expect {
+		t.Errorf("expect %q\ngot %q", expect, syn)
+	}
+
+	tree, _ := testTreeCmd()
+	tree.Func = nil
+
+	if syn := tree.Synopsis(); syn != "prefix <sub>" {
+		t.Errorf("expect %q\ngot %q", "prefix <sub>", syn)
+	}
+}
+
+func TestTriggerPos(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	r.Cmd.Add(testPosCmd())
+
+	for line, expect := range map[string]testPos{
+		"//cmd foo":              {User: "foo"},
+		"//cmd foo 3":            {User: "foo", Count: 3},
+		"//cmd foo 3 being rude": {User: "foo", Count: 3, Reason: []string{"being", "rude"}},
+	} {
+		tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if err != nil {
+			t.Errorf("trigger %q %q: %s", testPfx.Clean, line, err)
+
+			continue
+		}
+
+		got := tr.Pos.(testPos)
+		if len(got.Reason) == 0 {
+			got.Reason = nil
+		}
+
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("trigger %q: expect %#v\ngot %#v", line, expect, got)
+		}
+	}
+}
+
+func TestTriggerPosErr(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	r.Cmd.Add(testPosCmd())
+
+	strict := testPosCmd()
+	strict.Name = "strict"
+	strict.Pos = struct {
+		A string `pos:"a"`
+	}{}
+	r.Cmd.Add(strict)
+
+	for _, line := range []string{
+		"//cmd",
+		"//cmd foo three",
+		"//strict a b",
+	} {
+		_, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if !errors.Is(err, route.ErrArgs) {
+			t.Errorf("trigger %q: expect %v\ngot %v", line, route.ErrArgs, err)
+		}
+	}
+}
@@ -12,15 +12,40 @@ import (
 // KeyPrefix is the Confy key used to load/store prefixes.
 const KeyPrefix = "prefix"
 
+// KeyChannelPrefix is the Confy key used to load/store channel prefixes.
+const KeyChannelPrefix = "channelprefix"
+
+// KeyUserPrefix is the Confy key used to load/store user prefixes.
+const KeyUserPrefix = "userprefix"
+
 // GlobalGuildID is the GuildID used for global configurations.
 const GlobalGuildID = discord.NullGuildID
 
 // PrefixStore describes a concurrent-safe store of Guild-specific command prefixes.
+// Each Guild has an ordered list of prefixes.
+//
+// Channels and users can also have their own prefixes, which are used as described by ForLineAt.
+//
+// Changes are only kept in memory until Store is called, unless AutoStore is enabled.
+// Several PrefixStores can share a Confy, as described by Store and Reload.
 type PrefixStore struct {
 	Confy confy.Confy
 
-	ma map[discord.GuildID]string
+	ma map[discord.GuildID]prefixList
+	ch map[discord.ChannelID]prefixList
+	us map[discord.UserID]prefixList
 	mu sync.RWMutex
+
+	// local holds the entries changed through the PrefixStore since it last stored,
+	// with the version of their latest change.
+	local   map[prefixKey]uint64
+	version uint64
+
+	// storeMu keeps Stores in order, without holding mu while writing to the Confy.
+	storeMu sync.Mutex
+
+	auto autoStore
+	subs subscribers
 }
 
 // OpenPrefixStore creates a usable PrefixStore and calls Load.
@@ -28,8 +53,19 @@ func OpenPrefixStore(c confy.Confy) (*PrefixStore, error) {
 	pfxs := &PrefixStore{
 		Confy: c,
 
-		ma: map[discord.GuildID]string{},
+		ma: map[discord.GuildID]prefixList{},
+		ch: map[discord.ChannelID]prefixList{},
+		us: map[discord.UserID]prefixList{},
 		mu: sync.RWMutex{},
+
+		local:   nil,
+		version: 0,
+		storeMu: sync.Mutex{},
+
+		//nolint:exhaustivestruct // disabled until AutoStore is called
+		auto: autoStore{},
+		//nolint:exhaustivestruct // no subscribers yet
+		subs: subscribers{},
 	}
 
 	if err := pfxs.Load(); err != nil {
@@ -40,101 +76,511 @@ func OpenPrefixStore(c confy.Confy) (*PrefixStore, error) {
 }
 
 // Load updates the PrefixStore with data from the Confy.
+//
+// Both lists of prefixes and single prefixes are accepted for each Guild.
+// It is not an error for the Confy to have no channel or user prefixes yet.
 func (p *PrefixStore) Load() error {
 	p.mu.Lock()
-	err := p.Confy.Get(KeyPrefix, &p.ma)
-	p.mu.Unlock()
+	defer p.mu.Unlock()
 
+	err := p.Confy.Get(KeyPrefix, &p.ma)
 	if err != nil {
 		return fmt.Errorf("confy load %q: %w", KeyPrefix, err)
 	}
 
+	err = confyLoad(p.Confy, KeyChannelPrefix, &p.ch)
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyChannelPrefix, err)
+	}
+
+	err = confyLoad(p.Confy, KeyUserPrefix, &p.us)
+	if err != nil {
+		return fmt.Errorf("confy load %q: %w", KeyUserPrefix, err)
+	}
+
 	return nil
 }
 
 // Store updates the Confy with data from the PrefixStore.
+//
+// The Confy is read first, and merged as by Reload, so entries stored by other processes aren't lost.
+// Entries changed through this PrefixStore since it last stored replace theirs.
+//
+// Prefixes are always stored as lists, which migrates any single prefixes that were loaded.
 func (p *PrefixStore) Store() error {
-	p.mu.RLock()
-	err := p.Confy.Set(KeyPrefix, p.ma)
-	p.mu.RUnlock()
+	p.storeMu.Lock()
+	defer p.storeMu.Unlock()
+
+	fresh, err := p.fresh()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	events := p.merge(fresh)
+	snap := p.snapshot()
+	version := p.version
+	p.mu.Unlock()
+
+	for _, e := range events {
+		p.emit(e)
+	}
+
+	err = snap.store()
+	if err != nil {
+		return err
+	}
+
+	// changes made while storing are still local
+	p.mu.Lock()
+	for key, v := range p.local {
+		if v <= version {
+			delete(p.local, key)
+		}
+	}
+	p.mu.Unlock()
+
+	return nil
+}
+
+// snapshot copies the PrefixStore's data into a new PrefixStore, to be stored. p.mu must be held.
+func (p *PrefixStore) snapshot() *PrefixStore {
+	//nolint:exhaustivestruct // only used to store from
+	snap := &PrefixStore{
+		Confy: p.Confy,
+
+		ma: make(map[discord.GuildID]prefixList, len(p.ma)),
+		ch: make(map[discord.ChannelID]prefixList, len(p.ch)),
+		us: make(map[discord.UserID]prefixList, len(p.us)),
+	}
+
+	// prefixLists are replaced rather than changed, so they can be shared
+	for g, rules := range p.ma {
+		snap.ma[g] = rules
+	}
+
+	for ch, rules := range p.ch {
+		snap.ch[ch] = rules
+	}
+
+	for u, rules := range p.us {
+		snap.us[u] = rules
+	}
+
+	return snap
+}
 
+// store sets the PrefixStore's data in the Confy.
+func (p *PrefixStore) store() error {
+	err := p.Confy.Set(KeyPrefix, p.ma)
 	if err != nil {
 		return fmt.Errorf("confy store %q: %w", KeyPrefix, err)
 	}
 
+	err = p.Confy.Set(KeyChannelPrefix, p.ch)
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyChannelPrefix, err)
+	}
+
+	err = p.Confy.Set(KeyUserPrefix, p.us)
+	if err != nil {
+		return fmt.Errorf("confy store %q: %w", KeyUserPrefix, err)
+	}
+
 	return nil
 }
 
-// Get allows looking up a Prefix by GuildID.
+// Get allows looking up the first prefix for a GuildID. Use GetAll for all of them.
 func (p *PrefixStore) Get(g discord.GuildID) (string, bool) {
+	pfxvs, ok := p.GetAll(g)
+	if len(pfxvs) == 0 {
+		return "", ok
+	}
+
+	return pfxvs[0], ok
+}
+
+// GetAll allows looking up the prefixes for a GuildID.
+func (p *PrefixStore) GetAll(g discord.GuildID) ([]string, bool) {
 	p.mu.RLock()
-	pfxv, ok := p.ma[g]
+	rules, ok := p.ma[g]
 	p.mu.RUnlock()
 
-	return pfxv, ok
+	return rules.values(), ok
 }
 
-// Set allows storing a prefix for a given GuildID.
-func (p *PrefixStore) Set(g discord.GuildID, pfxv string) {
-	p.mu.Lock()
-	p.ma[g] = pfxv
-	p.mu.Unlock()
+// Set allows storing the prefixes for a given GuildID, replacing any it had.
+func (p *PrefixStore) Set(g discord.GuildID, pfxvs ...string) {
+	p.update(PrefixGuild, discord.Snowflake(g), replaceRules(newPrefixRules(pfxvs)))
+}
+
+// GetRules allows looking up the PrefixRules for a GuildID.
+func (p *PrefixStore) GetRules(g discord.GuildID) ([]PrefixRule, bool) {
+	p.mu.RLock()
+	rules, ok := p.ma[g]
+	p.mu.RUnlock()
+
+	return append([]PrefixRule(nil), rules...), ok
+}
+
+// SetRules allows storing the PrefixRules for a given GuildID, replacing any it had.
+func (p *PrefixStore) SetRules(g discord.GuildID, rules ...PrefixRule) error {
+	rules, err := prefixList(rules).compile()
+	if err != nil {
+		return err
+	}
+
+	p.update(PrefixGuild, discord.Snowflake(g), replaceRules(rules))
+
+	return nil
 }
 
-// Del removes the prefix for the given GuildID from the PrefixStore.
+// Add appends a prefix to those for a given GuildID, unless it's already there.
+func (p *PrefixStore) Add(g discord.GuildID, pfxv string) {
+	p.update(PrefixGuild, discord.Snowflake(g), func(rules prefixList, ok bool) (prefixList, bool) {
+		for _, rule := range rules {
+			if rule.Value == pfxv {
+				return rules, ok
+			}
+		}
+
+		return append(rules[:len(rules):len(rules)], PrefixRule{Value: pfxv}), true
+	})
+}
+
+// Remove removes a prefix from those for a given GuildID.
+func (p *PrefixStore) Remove(g discord.GuildID, pfxv string) {
+	p.update(PrefixGuild, discord.Snowflake(g), func(rules prefixList, ok bool) (prefixList, bool) {
+		kept := rules[:0:0]
+
+		for _, rule := range rules {
+			if rule.Value != pfxv {
+				kept = append(kept, rule)
+			}
+		}
+
+		return kept, ok
+	})
+}
+
+// Del removes the prefixes for the given GuildID from the PrefixStore.
 func (p *PrefixStore) Del(g discord.GuildID) {
+	p.update(PrefixGuild, discord.Snowflake(g), replaceRules(nil))
+}
+
+// For gets the prefixes for the given GuildID, falling back to those of GlobalGuildID.
+func (p *PrefixStore) For(g discord.GuildID) []string {
+	return p.rulesFor(g).values()
+}
+
+func (p *PrefixStore) rulesFor(g discord.GuildID) prefixList {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rules := p.ma[g]
+	if len(rules) == 0 {
+		rules = p.ma[GlobalGuildID]
+	}
+
+	return rules
+}
+
+// GetChannel allows looking up the first prefix for a ChannelID. Use GetChannelAll for all of them.
+func (p *PrefixStore) GetChannel(ch discord.ChannelID) (string, bool) {
+	pfxvs, ok := p.GetChannelAll(ch)
+	if len(pfxvs) == 0 {
+		return "", ok
+	}
+
+	return pfxvs[0], ok
+}
+
+// GetChannelAll allows looking up the prefixes for a ChannelID.
+func (p *PrefixStore) GetChannelAll(ch discord.ChannelID) ([]string, bool) {
+	p.mu.RLock()
+	rules, ok := p.ch[ch]
+	p.mu.RUnlock()
+
+	return rules.values(), ok
+}
+
+// SetChannel allows storing the prefixes for a given ChannelID, replacing any it had.
+// An empty prefix allows commands without a prefix in the channel.
+func (p *PrefixStore) SetChannel(ch discord.ChannelID, pfxvs ...string) {
+	p.update(PrefixChannel, discord.Snowflake(ch), replaceRules(newPrefixRules(pfxvs)))
+}
+
+// GetChannelRules allows looking up the PrefixRules for a ChannelID.
+func (p *PrefixStore) GetChannelRules(ch discord.ChannelID) ([]PrefixRule, bool) {
+	p.mu.RLock()
+	rules, ok := p.ch[ch]
+	p.mu.RUnlock()
+
+	return append([]PrefixRule(nil), rules...), ok
+}
+
+// SetChannelRules allows storing the PrefixRules for a given ChannelID, replacing any it had.
+func (p *PrefixStore) SetChannelRules(ch discord.ChannelID, rules ...PrefixRule) error {
+	rules, err := prefixList(rules).compile()
+	if err != nil {
+		return err
+	}
+
+	p.update(PrefixChannel, discord.Snowflake(ch), replaceRules(rules))
+
+	return nil
+}
+
+// DelChannel removes the prefixes for the given ChannelID from the PrefixStore.
+func (p *PrefixStore) DelChannel(ch discord.ChannelID) {
+	p.update(PrefixChannel, discord.Snowflake(ch), replaceRules(nil))
+}
+
+// GetUser allows looking up the first prefix for a UserID. Use GetUserAll for all of them.
+func (p *PrefixStore) GetUser(u discord.UserID) (string, bool) {
+	pfxvs, ok := p.GetUserAll(u)
+	if len(pfxvs) == 0 {
+		return "", ok
+	}
+
+	return pfxvs[0], ok
+}
+
+// GetUserAll allows looking up the prefixes for a UserID.
+func (p *PrefixStore) GetUserAll(u discord.UserID) ([]string, bool) {
+	p.mu.RLock()
+	rules, ok := p.us[u]
+	p.mu.RUnlock()
+
+	return rules.values(), ok
+}
+
+// SetUser allows storing the prefixes for a given UserID, replacing any they had.
+func (p *PrefixStore) SetUser(u discord.UserID, pfxvs ...string) {
+	p.update(PrefixUser, discord.Snowflake(u), replaceRules(newPrefixRules(pfxvs)))
+}
+
+// GetUserRules allows looking up the PrefixRules for a UserID.
+func (p *PrefixStore) GetUserRules(u discord.UserID) ([]PrefixRule, bool) {
+	p.mu.RLock()
+	rules, ok := p.us[u]
+	p.mu.RUnlock()
+
+	return append([]PrefixRule(nil), rules...), ok
+}
+
+// SetUserRules allows storing the PrefixRules for a given UserID, replacing any they had.
+func (p *PrefixStore) SetUserRules(u discord.UserID, rules ...PrefixRule) error {
+	rules, err := prefixList(rules).compile()
+	if err != nil {
+		return err
+	}
+
+	p.update(PrefixUser, discord.Snowflake(u), replaceRules(rules))
+
+	return nil
+}
+
+// DelUser removes the prefixes for the given UserID from the PrefixStore.
+func (p *PrefixStore) DelUser(u discord.UserID) {
+	p.update(PrefixUser, discord.Snowflake(u), replaceRules(nil))
+}
+
+// replaceRules makes an update func that replaces the PrefixRules with the given ones,
+// or deletes them if rules is nil.
+func replaceRules(rules prefixList) func(prefixList, bool) (prefixList, bool) {
+	return func(prefixList, bool) (prefixList, bool) {
+		return rules, rules != nil
+	}
+}
+
+// update changes the PrefixRules for the given scope and ID using f, which gets the current
+// PrefixRules and whether there are any, and returns the new ones and whether to keep them.
+//
+// If anything changed, AutoStore and subscribers are notified.
+func (p *PrefixStore) update(
+	scope PrefixScope,
+	id discord.Snowflake,
+	f func(prefixList, bool) (prefixList, bool),
+) {
 	p.mu.Lock()
-	delete(p.ma, g)
+	old, had := p.get(scope, id)
+	rules, has := f(old, had)
+	p.put(scope, id, rules, has)
+
+	same := had == has && old.equal(rules)
+	if !same {
+		if p.local == nil {
+			p.local = map[prefixKey]uint64{}
+		}
+
+		p.version++
+		p.local[prefixKey{Scope: scope, ID: id}] = p.version
+	}
 	p.mu.Unlock()
+
+	if same {
+		return
+	}
+
+	p.changed()
+	p.emit(PrefixEvent{Scope: scope, ID: id, Old: old, New: rules, Remote: false})
+}
+
+// get gets the PrefixRules for the given scope and ID. p.mu must be held.
+func (p *PrefixStore) get(scope PrefixScope, id discord.Snowflake) (prefixList, bool) {
+	var (
+		rules prefixList
+		ok    bool
+	)
+
+	switch scope {
+	case PrefixGuild:
+		rules, ok = p.ma[discord.GuildID(id)]
+	case PrefixChannel:
+		rules, ok = p.ch[discord.ChannelID(id)]
+	case PrefixUser:
+		rules, ok = p.us[discord.UserID(id)]
+	}
+
+	return rules, ok
+}
+
+// put sets or deletes the PrefixRules for the given scope and ID. p.mu must be held.
+func (p *PrefixStore) put(scope PrefixScope, id discord.Snowflake, rules prefixList, keep bool) {
+	switch scope {
+	case PrefixGuild:
+		if keep {
+			p.ma[discord.GuildID(id)] = rules
+		} else {
+			delete(p.ma, discord.GuildID(id))
+		}
+	case PrefixChannel:
+		if keep {
+			p.ch[discord.ChannelID(id)] = rules
+		} else {
+			delete(p.ch, discord.ChannelID(id))
+		}
+	case PrefixUser:
+		if keep {
+			p.us[discord.UserID(id)] = rules
+		} else {
+			delete(p.us, discord.UserID(id))
+		}
+	}
 }
 
 // Prefix is a command prefix.
 type Prefix struct {
 	Value string
 	Clean string
+
+	// Mention is whether the prefix is a mention of the bot.
+	Mention bool
+}
+
+// Bare gets an empty Prefix, for lines that don't need one.
+// Its Clean is the first global prefix, so that examples using it work anywhere.
+func (p *PrefixStore) Bare() Prefix {
+	clean := ""
+
+	for _, pfxv := range p.For(GlobalGuildID) {
+		if pfxv != "" {
+			clean = pfxv
+
+			break
+		}
+	}
+
+	return Prefix{Value: "", Clean: clean, Mention: false}
+}
+
+// Origin is where a line was sent, and by whom, for finding its prefix.
+type Origin struct {
+	Guild   discord.GuildID
+	Channel discord.ChannelID
+	User    discord.UserID
+
+	// Role is the bot's managed role in the Guild, whose mention is accepted as a prefix.
+	Role discord.RoleID
+
+	// NoMention disables mentions of the bot as prefixes.
+	NoMention bool
 }
 
 // ForLine finds the first suitable prefix that matches the given line.
+// Of the Guild's prefixes, the longest that matches is used.
+//
+// It is like ForLineAt, without channel or user prefixes.
 func (p *PrefixStore) ForLine(
 	g discord.GuildID,
 	me discord.User,
 	mme *discord.Member,
 	line string,
+) (Prefix, bool) {
+	return p.ForLineAt(Origin{Guild: g, Channel: 0, User: 0, Role: 0, NoMention: false}, me, mme, line)
+}
+
+// ForLineAt finds the first suitable prefix that matches the given line, from the given Origin.
+//
+// Prefixes are tried in this order, using the longest that matches from each list:
+//  1. the user's prefixes
+//  2. the channel's prefixes
+//  3. the Guild's prefixes, or if it has none, the global ones
+//  4. mentions of the bot, as "<@id>", "<@!id>", or "<@&role>" for its managed role
+//  5. an empty prefix, if a list from step 2 or 3 has one
+func (p *PrefixStore) ForLineAt(
+	o Origin,
+	me discord.User,
+	mme *discord.Member,
+	line string,
 ) (Prefix, bool) {
 	line = strings.TrimSpace(line)
 
-	// guild prefix
-	pfxv, ok := p.Get(g)
-	if !ok {
-		// fallback to default prefix
-		pfxv, ok = p.Get(GlobalGuildID)
+	p.mu.RLock()
+	user := p.us[o.User]
+	channel := p.ch[o.Channel]
+	p.mu.RUnlock()
+
+	// user prefixes
+	if pfx, ok, _ := user.longest(line); ok {
+		return pfx, true
 	}
 
-	if ok && strings.HasPrefix(line, pfxv) {
-		return Prefix{pfxv, pfxv}, true
+	// channel prefixes
+	pfx, ok, empty := channel.longest(line)
+	if ok {
+		return pfx, true
 	}
 
-	// member prefix
-	if mme != nil && strings.HasPrefix(line, mme.Mention()) {
-		pfx := Prefix{
-			Value: mme.Mention(),
-			Clean: "@" + me.Username + " ",
-		}
+	// guild prefixes, or default prefixes
+	pfx, ok, guildEmpty := p.rulesFor(o.Guild).longest(line)
+	if ok {
+		return pfx, true
+	}
 
-		if mme.Nick != "" {
-			pfx.Clean = "@" + mme.Nick + " "
-		}
+	empty = empty || guildEmpty
 
-		return pfx, true
+	// mentions of the bot
+	if !o.NoMention {
+		if mention, ok := mentionPrefix(line, me, o.Role); ok {
+			pfx := Prefix{
+				Value:   mention,
+				Clean:   "@" + me.Username + " ",
+				Mention: true,
+			}
+
+			if mme != nil && mme.Nick != "" {
+				pfx.Clean = "@" + mme.Nick + " "
+			}
+
+			return pfx, true
+		}
 	}
 
-	// user prefix
-	if strings.HasPrefix(line, me.Mention()) {
-		return Prefix{
-			Value: me.Mention(),
-			Clean: "@" + me.Username + " ",
-		}, true
+	if empty {
+		return Prefix{Value: "", Clean: "", Mention: false}, true
 	}
 
-	return Prefix{"", ""}, false
+	return Prefix{Value: "", Clean: "", Mention: false}, false
 }
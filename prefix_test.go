@@ -66,8 +66,9 @@ func TestForLineUser(t *testing.T) {
 
 	pfx, _ := r.Prefix.ForLine(discord.NullGuildID, testMe, nil, testMe.Mention())
 	expect := route.Prefix{
-		Value: testMe.Mention(),
-		Clean: "@" + testMe.Username + " ",
+		Value:   testMe.Mention(),
+		Clean:   "@" + testMe.Username + " ",
+		Mention: true,
 	}
 
 	if !reflect.DeepEqual(pfx, expect) {
@@ -88,8 +89,9 @@ func TestForLineMemberNick(t *testing.T) {
 
 	pfx, _ := r.Prefix.ForLine(guild, testMe, &testMMeNick, testMMeNick.Mention())
 	expect := route.Prefix{
-		Value: testMMeNick.Mention(),
-		Clean: "@" + testMMeNick.Nick + " ",
+		Value:   testMMeNick.Mention(),
+		Clean:   "@" + testMMeNick.Nick + " ",
+		Mention: true,
 	}
 
 	if !reflect.DeepEqual(pfx, expect) {
@@ -115,3 +117,211 @@ func TestForLineNil(t *testing.T) {
 
 	m.Eval()
 }
+
+func TestForLineLongest(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	r := testRoute(t, nil, testConfy())
+
+	r.Prefix.Set(guild, "!", "bot ", "!!")
+
+	tests := map[string]string{
+		"!cmd":     "!",
+		"!!cmd":    "!!",
+		"bot cmd":  "bot ",
+		"?cmd":     "",
+		"//global": "",
+	}
+
+	for line, expect := range tests {
+		pfx, ok := r.Prefix.ForLine(guild, testMe, nil, line)
+		if ok != (expect != "") || pfx.Value != expect {
+			t.Errorf("%q: expect %q, got %q (%t)", line, expect, pfx.Value, ok)
+		}
+	}
+
+	pfx, ok := r.Prefix.ForLine(1, testMe, nil, "//global")
+	if !ok || pfx.Value != testPfx.Value {
+		t.Errorf("expect global fallback, got %q (%t)", pfx.Value, ok)
+	}
+}
+
+func TestPrefixStoreAddRemove(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	r := testRoute(t, nil, testConfy())
+
+	r.Prefix.Add(guild, "!")
+	r.Prefix.Add(guild, "?")
+	r.Prefix.Add(guild, "!")
+	r.Prefix.Remove(guild, "?")
+
+	pfxvs, _ := r.Prefix.GetAll(guild)
+	if !reflect.DeepEqual(pfxvs, []string{"!"}) {
+		t.Errorf("expect %q, got %q", []string{"!"}, pfxvs)
+	}
+
+	r.Prefix.Add(guild, "?")
+
+	if pfxv, ok := r.Prefix.Get(guild); !ok || pfxv != "!" {
+		t.Errorf("expect first prefix %q, got %q", "!", pfxv)
+	}
+}
+
+func TestPrefixStoreLegacy(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	c := testConfy()
+
+	err := c.Set(route.KeyPrefix, map[discord.GuildID]string{
+		route.GlobalGuildID: "//",
+		guild:               "!",
+	})
+	if err != nil {
+		t.Fatalf("set legacy: %s", err)
+	}
+
+	pfxs, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	pfxs.Add(guild, "?")
+
+	err = pfxs.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	stored := map[discord.GuildID][]string{}
+
+	err = c.Get(route.KeyPrefix, &stored)
+	if err != nil {
+		t.Fatalf("expect migrated lists: %s", err)
+	}
+
+	expect := map[discord.GuildID][]string{
+		route.GlobalGuildID: {"//"},
+		guild:               {"!", "?"},
+	}
+
+	if !reflect.DeepEqual(stored, expect) {
+		t.Errorf("expect %v, got %v", expect, stored)
+	}
+}
+
+func TestForLineAtPrecedence(t *testing.T) {
+	t.Parallel()
+
+	const (
+		guild   = 1234567890
+		channel = 456
+		bare    = 789
+		user    = 999
+	)
+
+	r := testRoute(t, nil, testConfy())
+
+	r.Prefix.Set(guild, "!")
+	r.Prefix.SetChannel(channel, "$", "")
+	r.Prefix.SetChannel(bare, "")
+	r.Prefix.SetUser(user, "?")
+
+	tests := []struct {
+		name   string
+		o      route.Origin
+		line   string
+		expect string
+		ok     bool
+	}{
+		{"user", route.Origin{Guild: guild, Channel: channel, User: user}, "?cmd", "?", true},
+		{"user anywhere", route.Origin{Guild: 1, Channel: 1, User: user}, "?cmd", "?", true},
+		{"channel", route.Origin{Guild: guild, Channel: channel, User: user}, "$cmd", "$", true},
+		{"channel then guild", route.Origin{Guild: guild, Channel: channel, User: 1}, "!cmd", "!", true},
+		{"guild", route.Origin{Guild: guild, Channel: 1, User: user}, "!cmd", "!", true},
+		{"empty channel then guild", route.Origin{Guild: guild, Channel: bare, User: 1}, "!help", "!", true},
+		{"empty channel", route.Origin{Guild: guild, Channel: bare, User: 1}, "help", "", true},
+		{"global", route.Origin{Guild: 1, Channel: 1, User: 1}, "//cmd", "//", true},
+		{"no prefix", route.Origin{Guild: guild, Channel: channel, User: 1}, "cmd", "", true},
+		{"mention before empty", route.Origin{Guild: guild, Channel: channel}, testMe.Mention() + "cmd", testMe.Mention(), true},
+		{"none", route.Origin{Guild: guild, Channel: 1, User: 1}, "cmd", "", false},
+	}
+
+	for _, test := range tests {
+		pfx, ok := r.Prefix.ForLineAt(test.o, testMe, nil, test.line)
+		if ok != test.ok || pfx.Value != test.expect {
+			t.Errorf("%s: expect %q (%t), got %q (%t)", test.name, test.expect, test.ok, pfx.Value, ok)
+		}
+	}
+}
+
+func TestPrefixStoreOverrides(t *testing.T) {
+	t.Parallel()
+
+	const (
+		channel = 456
+		user    = 999
+	)
+
+	c := testConfy()
+
+	pfxs, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	pfxs.SetChannel(channel, "")
+	pfxs.SetUser(user, "?", "bot ")
+
+	err = pfxs.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	pfxs2, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	if pfxvs, ok := pfxs2.GetChannelAll(channel); !ok || !reflect.DeepEqual(pfxvs, []string{""}) {
+		t.Errorf("expect channel prefixes to load, got %q", pfxvs)
+	}
+
+	if pfxvs, ok := pfxs2.GetUserAll(user); !ok || !reflect.DeepEqual(pfxvs, []string{"?", "bot "}) {
+		t.Errorf("expect user prefixes to load, got %q", pfxvs)
+	}
+
+	pfxs2.DelChannel(channel)
+	pfxs2.DelUser(user)
+
+	if _, ok := pfxs2.GetChannel(channel); ok {
+		t.Error("expect channel prefixes deleted")
+	}
+
+	if _, ok := pfxs2.GetUser(user); ok {
+		t.Error("expect user prefixes deleted")
+	}
+}
+
+func TestPrefixStoreBare(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	expect := route.Prefix{Value: "", Clean: testPfx.Value, Mention: false}
+	if pfx := r.Prefix.Bare(); !reflect.DeepEqual(pfx, expect) {
+		t.Errorf("expect %#v, got %#v", expect, pfx)
+	}
+
+	r.Prefix.Del(route.GlobalGuildID)
+
+	if pfx := r.Prefix.Bare(); pfx.Clean != "" {
+		t.Errorf("expect empty clean, got %q", pfx.Clean)
+	}
+}
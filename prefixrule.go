@@ -0,0 +1,264 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	re2 "github.com/dlclark/regexp2"
+)
+
+// prefixTimeout limits how long a PrefixRule's regular expression may take to match a line.
+const prefixTimeout = 100 * time.Millisecond
+
+// PrefixRule describes how a prefix matches the start of a line.
+//
+// A PrefixRule with only a Value matches lines starting with exactly that Value,
+// and is stored in Confy as a plain string.
+type PrefixRule struct {
+	// Value is the prefix, or a regular expression if Regex is set.
+	Value string `json:"value"`
+
+	// Fold makes the prefix match case-insensitively.
+	Fold bool `json:"fold,omitempty"`
+
+	// Space makes any whitespace in Value match any amount of whitespace,
+	// and allows any amount of whitespace after the prefix.
+	Space bool `json:"space,omitempty"`
+
+	// Regex makes Value a regular expression, using regexp2, anchored to the start of the line.
+	Regex bool `json:"regex,omitempty"`
+
+	// Clean is shown to users instead of the prefix. If it's empty, Value is shown,
+	// or the matched text if Regex is set.
+	Clean string `json:"clean,omitempty"`
+
+	re *re2.Regexp
+}
+
+// plainPrefixRule is PrefixRule without its JSON methods.
+type plainPrefixRule PrefixRule
+
+// MarshalJSON stores the PrefixRule as a string if it only has a Value, and as an object otherwise.
+func (r PrefixRule) MarshalJSON() ([]byte, error) {
+	if !r.Fold && !r.Space && !r.Regex && r.Clean == "" {
+		return json.Marshal(r.Value)
+	}
+
+	return json.Marshal(plainPrefixRule(r))
+}
+
+// UnmarshalJSON loads the PrefixRule from a string or an object, and compiles it.
+func (r *PrefixRule) UnmarshalJSON(data []byte) error {
+	var pfxv string
+	if err := json.Unmarshal(data, &pfxv); err == nil {
+		*r = PrefixRule{Value: pfxv}
+
+		return nil
+	}
+
+	var plain plainPrefixRule
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return fmt.Errorf("prefix rule: %w", err)
+	}
+
+	*r = PrefixRule(plain)
+
+	return r.compile()
+}
+
+// spacePattern escapes the Value of a Space rule, so each run of whitespace in it,
+// including at its end, matches one or more whitespace characters.
+// Whitespace at its start is ignored, like at the start of lines.
+func spacePattern(v string) string {
+	words := strings.Fields(v)
+	for i, word := range words {
+		words[i] = re2.Escape(word)
+	}
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	pat := strings.Join(words, `\s+`)
+
+	if strings.TrimRightFunc(v, unicode.IsSpace) != v {
+		pat += `\s+`
+	}
+
+	return pat
+}
+
+// compile prepares the regular expression for the PrefixRule, if it needs one.
+func (r *PrefixRule) compile() error {
+	r.re = nil
+
+	if !r.Fold && !r.Space && !r.Regex {
+		return nil
+	}
+
+	pat := r.Value
+
+	if !r.Regex {
+		pat = re2.Escape(pat)
+
+		if r.Space {
+			pat = spacePattern(r.Value)
+		}
+	}
+
+	pat = `\A(?:` + pat + `)`
+	if r.Space {
+		pat += `\s*`
+	}
+
+	opts := re2.RegexOptions(re2.None)
+	if r.Fold {
+		opts = re2.IgnoreCase
+	}
+
+	re, err := re2.Compile(pat, opts)
+	if err != nil {
+		return fmt.Errorf("compile prefix %q: %w", r.Value, err)
+	}
+
+	re.MatchTimeout = prefixTimeout
+	r.re = re
+
+	return nil
+}
+
+// empty checks whether the PrefixRule allows lines without a prefix.
+func (r PrefixRule) empty() bool {
+	return r.Value == "" && !r.Regex
+}
+
+// match finds the text matched by the PrefixRule at the start of the line.
+func (r PrefixRule) match(line string) (string, bool) {
+	if r.re == nil {
+		return r.Value, strings.HasPrefix(line, r.Value)
+	}
+
+	m, err := r.re.FindStringMatch(line)
+	if err != nil || m == nil {
+		return "", false
+	}
+
+	return m.String(), true
+}
+
+// prefix makes a Prefix for the text matched by the PrefixRule.
+func (r PrefixRule) prefix(matched string) Prefix {
+	switch {
+	case r.Clean != "":
+		return Prefix{Value: matched, Clean: r.Clean, Mention: false}
+	case r.Regex:
+		return Prefix{Value: matched, Clean: matched, Mention: false}
+	default:
+		return Prefix{Value: matched, Clean: r.Value, Mention: false}
+	}
+}
+
+// newPrefixRules makes plain PrefixRules from the given prefixes.
+func newPrefixRules(pfxvs []string) prefixList {
+	rules := make(prefixList, len(pfxvs))
+
+	for i, pfxv := range pfxvs {
+		rules[i] = PrefixRule{Value: pfxv}
+	}
+
+	return rules
+}
+
+// prefixList is a list of PrefixRules, which can also be loaded from a single string,
+// as stored by older versions.
+type prefixList []PrefixRule
+
+func (l *prefixList) UnmarshalJSON(data []byte) error {
+	var pfxv string
+	if err := json.Unmarshal(data, &pfxv); err == nil {
+		*l = prefixList{{Value: pfxv}}
+
+		return nil
+	}
+
+	var rules []PrefixRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("prefix list: %w", err)
+	}
+
+	*l = rules
+
+	return nil
+}
+
+// compile compiles copies of the given PrefixRules.
+func (l prefixList) compile() (prefixList, error) {
+	rules := make(prefixList, len(l))
+
+	for i, rule := range l {
+		err := rule.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		rules[i] = rule
+	}
+
+	return rules, nil
+}
+
+// equal checks whether the lists have the same PrefixRules.
+func (l prefixList) equal(o prefixList) bool {
+	if len(l) != len(o) {
+		return false
+	}
+
+	for i := range l {
+		a, b := l[i], o[i]
+		if a.Value != b.Value || a.Fold != b.Fold || a.Space != b.Space ||
+			a.Regex != b.Regex || a.Clean != b.Clean {
+			return false
+		}
+	}
+
+	return true
+}
+
+// values gets the Value of each PrefixRule.
+func (l prefixList) values() []string {
+	if l == nil {
+		return nil
+	}
+
+	pfxvs := make([]string, len(l))
+
+	for i, rule := range l {
+		pfxvs[i] = rule.Value
+	}
+
+	return pfxvs
+}
+
+// longest finds the longest match of the non-empty PrefixRules at the start of the line,
+// and whether any of them are empty.
+func (l prefixList) longest(line string) (Prefix, bool, bool) {
+	best, ok, empty := Prefix{Value: "", Clean: "", Mention: false}, false, false
+
+	for _, rule := range l {
+		if rule.empty() {
+			empty = true
+
+			continue
+		}
+
+		matched, match := rule.match(line)
+		if match && len(matched) > len(best.Value) {
+			best, ok = rule.prefix(matched), true
+		}
+	}
+
+	return best, ok, empty
+}
@@ -0,0 +1,158 @@
+package route_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/go-snart/route"
+)
+
+func TestPrefixRules(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	r := testRoute(t, nil, testConfy())
+
+	err := r.Prefix.SetRules(guild,
+		route.PrefixRule{Value: "!"},
+		route.PrefixRule{Value: "bot,", Fold: true, Space: true},
+		route.PrefixRule{Value: "hey  bot", Space: true},
+		route.PrefixRule{Value: "bot ", Space: true},
+		route.PrefixRule{Value: " yo", Space: true},
+		route.PrefixRule{Value: `(?:ok|okay)\s+bot\b`, Regex: true, Fold: true, Clean: "ok bot "},
+		route.PrefixRule{Value: `\?+`, Regex: true},
+	)
+	if err != nil {
+		t.Fatalf("set rules: %s", err)
+	}
+
+	tests := []struct {
+		line  string
+		value string
+		clean string
+	}{
+		{"!cmd", "!", "!"},
+		{"Bot, do x", "Bot, ", "bot,"},
+		{"BOT,do x", "BOT,", "bot,"},
+		{"hey \t bot cmd", "hey \t bot ", "hey  bot"},
+		{"bot \t cmd", "bot \t ", "bot "},
+		{"yo cmd", "yo ", " yo"},
+		{" yo cmd", "yo ", " yo"},
+		{"Okay   BOT cmd", "Okay   BOT", "ok bot "},
+		{"???cmd", "???", "???"},
+	}
+
+	for _, test := range tests {
+		pfx, ok := r.Prefix.ForLine(guild, testMe, nil, test.line)
+		expect := route.Prefix{Value: test.value, Clean: test.clean}
+
+		if !ok || !reflect.DeepEqual(pfx, expect) {
+			t.Errorf("%q: expect %#v, got %#v (%t)", test.line, expect, pfx, ok)
+		}
+	}
+
+	for _, line := range []string{"heybot cmd", "okbot cmd", "xbot, cmd", "bottle of water"} {
+		if pfx, ok := r.Prefix.ForLine(guild, testMe, nil, line); ok {
+			t.Errorf("%q: expect no match, got %#v", line, pfx)
+		}
+	}
+}
+
+func TestPrefixRulesTrigger(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, run := testCmd()
+	r.Cmd.Add(cmd)
+
+	err := r.Prefix.SetRules(guild, route.PrefixRule{Value: "bot,", Fold: true, Space: true})
+	if err != nil {
+		t.Fatalf("set rules: %s", err)
+	}
+
+	const line = "  BOT,   " + testName + " -run=x"
+
+	pfx, ok := r.Prefix.ForLine(guild, testMe, nil, line)
+	if !ok {
+		t.Fatalf("expect match for %q", line)
+	}
+
+	tr, err := r.Trigger(pfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger: %s", err)
+	}
+
+	err = tr.Run()
+	if err != nil || *run != "x" {
+		t.Errorf("expect run %q, got %q (%v)", "x", *run, err)
+	}
+}
+
+func TestPrefixRulesBadRegex(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	err := r.Prefix.SetRules(1, route.PrefixRule{Value: "(", Regex: true})
+	if err == nil {
+		t.Error("expect error")
+	}
+}
+
+func TestPrefixRulesStore(t *testing.T) {
+	t.Parallel()
+
+	const guild = 1234567890
+
+	c := testConfy()
+
+	pfxs, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	err = pfxs.SetUserRules(1, route.PrefixRule{Value: "yo", Fold: true})
+	if err != nil {
+		t.Fatalf("set user rules: %s", err)
+	}
+
+	err = pfxs.SetChannelRules(2, route.PrefixRule{Value: "$"})
+	if err != nil {
+		t.Fatalf("set channel rules: %s", err)
+	}
+
+	pfxs.Set(guild, "!")
+
+	err = pfxs.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	stored := map[discord.GuildID][]string{}
+
+	err = c.Get(route.KeyPrefix, &stored)
+	if err != nil || !reflect.DeepEqual(stored[guild], []string{"!"}) {
+		t.Errorf("expect plain rules stored as strings, got %v (%v)", stored, err)
+	}
+
+	pfxs2, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	pfx, ok := pfxs2.ForLineAt(route.Origin{User: 1}, testMe, nil, "YO cmd")
+	if !ok || pfx.Value != "YO" {
+		t.Errorf("expect loaded rule to match, got %#v (%t)", pfx, ok)
+	}
+
+	rules, _ := pfxs2.GetChannelRules(2)
+	if len(rules) != 1 || rules[0].Value != "$" {
+		t.Errorf("expect channel rules to load, got %v", rules)
+	}
+}
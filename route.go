@@ -2,10 +2,13 @@
 package route
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/diamondburned/arikawa/v2/gateway"
@@ -23,6 +26,42 @@ type Route struct {
 
 	Prefix *PrefixStore
 	Cmd    *CmdStore
+	Conf   *ConfStore
+	Conv   *ConvStore
+	ACL    *ACLStore
+
+	Cooldown *CooldownStore
+
+	// Owners are the users allowed to run OwnerOnly Cmds.
+	Owners []discord.UserID
+
+	// Context is the base Context for Triggers. Cancelling it cancels in-flight Cmds.
+	Context context.Context
+
+	// Timeout limits how long a Cmd may run, unless the Cmd sets its own. Zero means no limit.
+	Timeout time.Duration
+
+	// Parser selects how Flags are parsed, unless the Cmd sets its own.
+	Parser Parser
+
+	// Dispatch selects which parts of a message are handled as commands.
+	Dispatch Dispatch
+
+	// Logger receives errors from handling messages, at a level depending on their Class.
+	Logger Logger
+
+	// ErrorHandler is called with errors from handling messages, after they are logged.
+	ErrorHandler ErrorHandler
+
+	// DMNoPrefix allows commands without a prefix in DMs. Prefixes still work there too.
+	DMNoPrefix bool
+
+	// Executor runs lines concurrently, if it is set. Otherwise, Handle runs them itself.
+	Executor *Executor
+
+	mw    []Middleware
+	catMW map[string][]Middleware
+	mwMu  sync.RWMutex
 }
 
 // New makes an empty Route with the given State.
@@ -32,12 +71,43 @@ func New(s *state.State, c confy.Confy) (*Route, error) {
 		return nil, fmt.Errorf("confy load %q: %w", KeyPrefix, err)
 	}
 
+	confs, err := OpenConfStore(c)
+	if err != nil {
+		return nil, fmt.Errorf("confy load %q: %w", KeyGuildConf, err)
+	}
+
+	acls, err := OpenACLStore(c)
+	if err != nil {
+		return nil, fmt.Errorf("confy load %q: %w", KeyACL, err)
+	}
+
 	return &Route{
 		State: s,
 		Confy: c,
 
 		Prefix: pfxs,
 		Cmd:    NewCmdStore(),
+		Conf:   confs,
+		Conv:   NewConvStore(),
+		ACL:    acls,
+
+		Cooldown: NewCooldownStore(),
+		Owners:   nil,
+
+		Context: context.Background(),
+		Timeout: 0,
+		Parser:  ParserDefault,
+
+		Dispatch: DispatchLines,
+		Logger:   StdLogger{Log: nil, Verbose: false},
+
+		ErrorHandler: DefaultErrorHandler,
+		DMNoPrefix:   false,
+		Executor:     nil,
+
+		mw:    nil,
+		catMW: map[string][]Middleware{},
+		mwMu:  sync.RWMutex{},
 	}, nil
 }
 
@@ -49,7 +119,7 @@ func (r *Route) Handle(m *gateway.MessageCreateEvent) {
 
 	me, err := r.State.Me()
 	if err != nil {
-		log.Printf("error: get me: %s", err)
+		r.Logger.Error("get me", "err", err)
 
 		return
 	}
@@ -58,33 +128,189 @@ func (r *Route) Handle(m *gateway.MessageCreateEvent) {
 		return
 	}
 
-	mme, _ := r.State.Member(m.GuildID, me.ID)
+	var mme *discord.Member
+	if m.GuildID.IsValid() {
+		mme, _ = r.State.Member(m.GuildID, me.ID)
+	}
+
+	for _, dl := range r.Dispatch.dispatch(m.Message.Content) {
+		dl := dl
+		run := func() {
+			t, err := r.handleLine(m, dl, *me, mme)
+			if err != nil {
+				r.report(m, t, dl.line, err)
+			}
+		}
+
+		if r.Executor == nil {
+			run()
 
-	lines := strings.Split(m.Message.Content, "\n")
+			continue
+		}
 
-	for _, line := range lines {
-		err := r.handleLine(m, line, *me, mme)
+		err := r.Executor.Submit(r.Executor.key(m), run)
 		if err != nil {
-			log.Printf("error: handle line %q: %s", line, err)
+			r.report(m, nil, dl.line, err)
 		}
 	}
 }
 
-func (r *Route) handleLine(m *gateway.MessageCreateEvent, line string, me discord.User, mme *discord.Member) error {
-	pfx, ok := r.Prefix.ForLine(m.GuildID, me, mme, line)
+// Shutdown waits for lines being handled by the Executor to finish, or for the Context to be done.
+// To cancel in-flight Cmds, cancel the Route's Context.
+func (r *Route) Shutdown(ctx context.Context) error {
+	if r.Executor == nil {
+		return nil
+	}
+
+	return r.Executor.Shutdown(ctx)
+}
+
+// report logs an error from handling a line, at a level depending on its Class,
+// and passes it to the ErrorHandler. The Trigger may be nil or partially filled.
+func (r *Route) report(m *gateway.MessageCreateEvent, t *Trigger, line string, err error) {
+	class := Classify(err)
+	args := []interface{}{
+		"err", err,
+		"class", class,
+		"guild", m.GuildID,
+		"channel", m.ChannelID,
+		"user", m.Author.ID,
+		"line", line,
+	}
+
+	var p *PanicError
+	if errors.As(err, &p) {
+		args = append(args, "stack", string(p.Stack))
+	}
+
+	if t != nil && t.Name != "" {
+		name := t.Name
+		if len(t.Path) > 0 {
+			name = t.PathName()
+		}
+
+		args = append(args, "command", name)
+	}
+
+	switch class {
+	case ClassIgnore:
+		r.Logger.Debug("handle line", args...)
+	case ClassUser:
+		r.Logger.Info("handle line", args...)
+	case ClassInternal:
+		fallthrough
+	default:
+		r.Logger.Error("handle line", args...)
+	}
+
+	if r.ErrorHandler != nil {
+		r.ErrorHandler(t, class, err)
+	}
+}
+
+func (r *Route) handleLine(
+	m *gateway.MessageCreateEvent,
+	dl dispatchLine,
+	me discord.User,
+	mme *discord.Member,
+) (t *Trigger, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	o := Origin{
+		Guild:     m.GuildID,
+		Channel:   m.ChannelID,
+		User:      m.Author.ID,
+		Role:      0,
+		NoMention: r.Conf.For(m.GuildID).NoMention,
+	}
+
+	if !o.NoMention && m.GuildID.IsValid() && strings.HasPrefix(strings.TrimSpace(dl.line), "<@&") {
+		o.Role = r.managedRole(m.GuildID, mme)
+	}
+
+	pfx, ok := r.Prefix.ForLineAt(o, me, mme, dl.line)
+	if !ok && r.DMNoPrefix && !m.GuildID.IsValid() {
+		pfx, ok = r.Prefix.Bare(), true
+	}
+
 	if !ok {
-		return ErrNoLinePrefix
+		return nil, ErrNoLinePrefix
+	}
+
+	t, err = r.Trigger(pfx, m.Message, dl.line)
+	t.Body = dl.body
+
+	if errors.Is(err, ErrNoCmd) {
+		r.noCmd(t)
+	}
+
+	if errors.Is(err, ErrCmdNotFound) {
+		if serr := t.Suggest(); serr != nil {
+			r.Logger.Warn("suggest", "err", serr)
+		}
 	}
 
-	t, err := r.Trigger(pfx, m.Message, line)
 	if err != nil {
-		return fmt.Errorf("get trigger: %w", err)
+		return t, fmt.Errorf("get trigger: %w", err)
 	}
 
-	err = t.Command.Func(t)
+	err = t.guard()
 	if err != nil {
-		return fmt.Errorf("run trigger: %w", err)
+		return t, fmt.Errorf("guard: %w", err)
 	}
 
-	return nil
+	err = t.checkACL()
+	if err != nil {
+		return t, fmt.Errorf("acl: %w", err)
+	}
+
+	err = t.takeCooldowns()
+	if err != nil {
+		return t, fmt.Errorf("cooldown: %w", err)
+	}
+
+	err = t.Run()
+	if err != nil {
+		return t, fmt.Errorf("run trigger: %w", err)
+	}
+
+	return t, nil
+}
+
+// noCmd replies to a Trigger without a command to run.
+//
+// A bare mention of the bot gets MentionHelp, and a group Cmd without a subcommand gets its Usage,
+// if the user could run it.
+func (r *Route) noCmd(t *Trigger) {
+	switch {
+	case len(t.Path) == 0:
+		if !t.Prefix.Mention {
+			return
+		}
+
+		if err := t.MentionHelp(); err != nil {
+			r.Logger.Warn("mention help", "err", err)
+		}
+	case t.guard() == nil && t.checkACL() == nil:
+		t.Usage()
+	}
+}
+
+// PanicError is an error made from a recovered panic, while handling a line.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Class gets the Class of the PanicError, which is always ClassInternal.
+func (p *PanicError) Class() Class {
+	return ClassInternal
 }
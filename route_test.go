@@ -1,8 +1,10 @@
 package route_test
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/diamondburned/arikawa/v2/discord"
@@ -189,14 +191,22 @@ func TestHandleCommandNotFound(t *testing.T) {
 	c := testConfy()
 	r := testRoute(t, s, c)
 
-	const guild = 123
+	const (
+		guild   = 123
+		channel = 456
+	)
 
 	m.Me(testMe)
 	m.Member(guild, testMMe)
+	m.SendMessage(nil, discord.Message{
+		ChannelID: channel,
+		Content:   "my prefix here is `//`. try `//<command> -help` for help with a command.",
+	})
 
 	r.Handle(&gateway.MessageCreateEvent{
 		Message: discord.Message{
-			GuildID: guild,
+			GuildID:   guild,
+			ChannelID: channel,
 			Author: discord.User{
 				ID: 999,
 			},
@@ -220,14 +230,27 @@ func TestHandleRunError(t *testing.T) {
 
 	r.Cmd.Add(cmd)
 
-	const guild = 123
+	const (
+		guild   = 123
+		channel = 456
+	)
 
 	m.Me(testMe)
 	m.Member(guild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: route.InternalErrorMsg,
+		},
+		discord.Message{
+			ChannelID: channel,
+		},
+	)
 
 	r.Handle(&gateway.MessageCreateEvent{
 		Message: discord.Message{
-			GuildID: guild,
+			GuildID:   guild,
+			ChannelID: channel,
 			Author: discord.User{
 				ID: 999,
 			},
@@ -306,3 +329,156 @@ func TestHandleMeError(t *testing.T) {
 
 	m.Eval()
 }
+
+func TestHandlePanic(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	c := testConfy()
+	r := testRoute(t, s, c)
+	l := &testLogger{}
+	r.Logger = l
+
+	cmd, _ := testCmd()
+	cmd.Func = func(*route.Trigger) error {
+		panic("oh no")
+	}
+
+	r.Cmd.Add(cmd)
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	m.SendMessage(
+		&discord.Embed{
+			Title:       "error",
+			Description: route.InternalErrorMsg,
+		},
+		discord.Message{
+			ChannelID: channel,
+		},
+	)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: channel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testPfx.Value + cmd.Name,
+		},
+	})
+
+	if len(l.entries) != 1 {
+		t.Fatalf("expect 1 entry, got %#v", l.entries)
+	}
+
+	err, _ := l.entries[0].args["err"].(error)
+
+	var p *route.PanicError
+	if !errors.As(err, &p) || p.Value != "oh no" {
+		t.Errorf("expect panic error, got %v", err)
+	}
+
+	if stack, _ := l.entries[0].args["stack"].(string); !strings.Contains(stack, "TestHandlePanic") {
+		t.Errorf("expect stack trace, got %q", stack)
+	}
+
+	m.Eval()
+}
+
+func TestHandleNilFlags(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	c := testConfy()
+	r := testRoute(t, s, c)
+
+	args := []string(nil)
+
+	cmd, _ := testCmd()
+	cmd.Flags = nil
+	cmd.Func = func(t *route.Trigger) error {
+		args = t.Args
+
+		return nil
+	}
+
+	r.Cmd.Add(cmd)
+
+	const guild = 123
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID: guild,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testPfx.Value + cmd.Name + " foo",
+		},
+	})
+
+	if len(args) != 1 || args[0] != "foo" {
+		t.Errorf("expect %v\ngot %v", []string{"foo"}, args)
+	}
+
+	m.Eval()
+}
+
+func TestHandleDMNoPrefix(t *testing.T) {
+	t.Parallel()
+	m, s := dismock.NewState(t)
+	c := testConfy()
+	r := testRoute(t, s, c)
+	r.DMNoPrefix = true
+
+	cmd, run := testCmd()
+	r.Cmd.Add(cmd)
+
+	for content, expect := range map[string]string{
+		cmd.Name + " -run=bare":                   "bare",
+		testPfx.Value + cmd.Name + " -run=prefix": "prefix",
+	} {
+		m.Me(testMe)
+
+		r.Handle(&gateway.MessageCreateEvent{
+			Message: discord.Message{
+				Author: discord.User{
+					ID: 999,
+				},
+				Content: content,
+			},
+		})
+
+		if *run != expect {
+			t.Errorf("%q: expect %q, got %q", content, expect, *run)
+		}
+	}
+
+	m.Me(testMe)
+	m.Member(123, testMMe)
+
+	*run = ""
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID: 123,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: cmd.Name + " -run=guild",
+		},
+	})
+
+	if *run != "" {
+		t.Errorf("expect no run in guild, got %q", *run)
+	}
+
+	m.Eval()
+}
@@ -0,0 +1,185 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnterminated occurs when a quote or code span in a line isn't closed.
+var ErrUnterminated = errors.New("unterminated quote")
+
+// Token is a single argument from a line.
+type Token struct {
+	Text string
+
+	// Quote is the character the Token was wrapped in: '"', '\'', '`', or 0 for none.
+	Quote rune
+
+	// Fenced is true for fenced code blocks, like ```go\ncode\n```.
+	Fenced bool
+
+	// Lang is the language hint of a fenced code block, if any.
+	Lang string
+}
+
+// split breaks a line into Tokens.
+//
+// Tokens are separated by whitespace. Double quotes group words and allow backslash escapes,
+// single quotes group words literally, and backslashes escape the next character elsewhere.
+// Quotes only group at the start of a Token, so words like "don't" are left alone,
+// and text right after a closing quote continues the Token, so `"a"b` is "ab".
+// Runs of backticks group everything up to a matching run, including newlines.
+func split(s string) ([]Token, error) {
+	toks := []Token(nil)
+
+	for i := 0; ; {
+		for i < len(s) {
+			r, n := utf8.DecodeRuneInString(s[i:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+
+			i += n
+		}
+
+		if i >= len(s) {
+			return toks, nil
+		}
+
+		tok, n, err := scanToken(s[i:])
+		if err != nil {
+			return toks, fmt.Errorf("at %d: %w", i, err)
+		}
+
+		toks = append(toks, tok)
+		i += n
+	}
+}
+
+func scanToken(s string) (Token, int, error) {
+	switch s[0] {
+	case '"', '\'':
+		return scanQuote(s)
+	case '`':
+		return scanCode(s)
+	default:
+		return scanWord(s)
+	}
+}
+
+func scanWord(s string) (Token, int, error) {
+	var b *strings.Builder
+
+	i := 0
+
+	for i < len(s) {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			break
+		}
+
+		if r == '\\' && i+n < len(s) {
+			if b == nil {
+				b = &strings.Builder{}
+				b.WriteString(s[:i])
+			}
+
+			r, n = utf8.DecodeRuneInString(s[i+1:])
+			b.WriteRune(r)
+			i += 1 + n
+
+			continue
+		}
+
+		if b != nil {
+			b.WriteRune(r)
+		}
+
+		i += n
+	}
+
+	text := s[:i]
+	if b != nil {
+		text = b.String()
+	}
+
+	return Token{Text: text, Quote: 0, Fenced: false, Lang: ""}, i, nil
+}
+
+func scanQuote(s string) (Token, int, error) {
+	q := s[0]
+
+	var b *strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == q:
+			text := s[1:i]
+			if b != nil {
+				text = b.String()
+			}
+
+			tok := Token{Text: text, Quote: rune(q), Fenced: false, Lang: ""}
+
+			return joinToken(tok, s, i+1)
+		case q == '"' && s[i] == '\\' && i+1 < len(s):
+			if b == nil {
+				b = &strings.Builder{}
+				b.WriteString(s[1:i])
+			}
+
+			i++
+			b.WriteByte(s[i])
+		case b != nil:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return Token{}, 0, fmt.Errorf("%w: %c", ErrUnterminated, q)
+}
+
+// joinToken continues a quoted Token with whatever follows it up to whitespace, like shells do,
+// so `"a"b` is one Token.
+func joinToken(tok Token, s string, i int) (Token, int, error) {
+	if i >= len(s) {
+		return tok, i, nil
+	}
+
+	if r, _ := utf8.DecodeRuneInString(s[i:]); unicode.IsSpace(r) {
+		return tok, i, nil
+	}
+
+	next, n, err := scanToken(s[i:])
+	if err != nil {
+		return Token{}, 0, err
+	}
+
+	tok.Text += next.Text
+
+	return tok, i + n, nil
+}
+
+func scanCode(s string) (Token, int, error) {
+	n := len(s) - len(strings.TrimLeft(s, "`"))
+	fence := s[:n]
+
+	end := strings.Index(s[n:], fence)
+	if end < 0 {
+		return Token{}, 0, fmt.Errorf("%w: %s", ErrUnterminated, fence)
+	}
+
+	tok := Token{Text: s[n : n+end], Quote: '`', Fenced: n >= 3, Lang: ""}
+
+	if tok.Fenced {
+		if nl := strings.IndexByte(tok.Text, '\n'); nl >= 0 && !strings.ContainsAny(tok.Text[:nl], " \t") {
+			tok.Lang, tok.Text = tok.Text[:nl], tok.Text[nl+1:]
+		}
+
+		tok.Text = strings.TrimSuffix(tok.Text, "\n")
+	}
+
+	return tok, n + end + n, nil
+}
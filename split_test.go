@@ -0,0 +1,86 @@
+package route_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+
+	"github.com/go-snart/route"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	for line, expect := range map[string][]string{
+		`//cmd a  b`:                   {"a", "b"},
+		`//cmd "two words" 'it s'`:     {"two words", "it s"},
+		`//cmd "say \"hi\"" a\ b`:      {`say "hi"`, "a b"},
+		`//cmd don't 'lit \n'`:         {"don't", `lit \n`},
+		"//cmd `a b` ``c ` d``":        {"a b", "c ` d"},
+		"//cmd x ```go\nfunc(){}\n```": {"x", "func(){}"},
+		`//cmd "a"b 'c'"d e" "f"g'h`:   {"ab", "cd e", "fg'h"},
+	} {
+		tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if err != nil {
+			t.Errorf("trigger %q %q: %s", testPfx.Clean, line, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(tr.Args, expect) {
+			t.Errorf("trigger %q: expect %q\ngot %q", line, expect, tr.Args)
+		}
+	}
+}
+
+func TestSplitBlocks(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd ```go\nfmt.Println(1)\n``` `inline` ```\nplain```"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	expect := []route.Token{
+		{Text: "fmt.Println(1)", Quote: '`', Fenced: true, Lang: "go"},
+		{Text: "plain", Quote: '`', Fenced: true, Lang: ""},
+	}
+
+	if blocks := tr.Blocks(); !reflect.DeepEqual(blocks, expect) {
+		t.Errorf("expect %#v\ngot %#v", expect, blocks)
+	}
+}
+
+func TestSplitUnterminated(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	for _, line := range []string{
+		`//cmd "open`,
+		`//cmd 'open`,
+		"//cmd ```go\nopen",
+	} {
+		_, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if !errors.Is(err, route.ErrUnterminated) {
+			t.Errorf("trigger %q: expect %v\ngot %v", line, route.ErrUnterminated, err)
+		}
+	}
+}
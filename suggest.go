@@ -0,0 +1,168 @@
+package route
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultSuggestions is the number of similar commands suggested when a command isn't found.
+const DefaultSuggestions = 3
+
+// Suggest finds up to max Cmd names similar to the given name, most similar first.
+//
+// Names starting with the given name are most similar, followed by names and aliases
+// within a small edit distance. If hidden is true, Cmds with the Hide flag will be included.
+func (c *CmdStore) Suggest(name string, max int, hidden bool) []string {
+	c.mu.RLock()
+	cmds := make([]Cmd, 0, len(c.ma))
+
+	for _, cmd := range c.ma {
+		cmds = append(cmds, cmd)
+	}
+	c.mu.RUnlock()
+
+	return suggest(c.key, name, cmds, max, hidden)
+}
+
+// SuggestSub finds up to max subcommand names of the given Cmd similar to the given name,
+// using the CmdStore's lookup rules.
+func (c *CmdStore) SuggestSub(cmd Cmd, name string, max int, hidden bool) []string {
+	return suggest(c.key, name, cmd.Subs, max, hidden)
+}
+
+type suggestion struct {
+	name string
+	dist int
+}
+
+func suggest(key func(string) string, name string, cmds []Cmd, max int, hidden bool) []string {
+	k := key(name)
+	if k == "" {
+		return nil
+	}
+
+	// allow roughly one typo per three runes
+	limit := len([]rune(k))/3 + 1
+	sugs := []suggestion(nil)
+
+	for _, cmd := range cmds {
+		if cmd.Hide && !hidden {
+			continue
+		}
+
+		best := limit + 1
+
+		for _, cand := range append([]string{cmd.Name}, cmd.Aliases...) {
+			ck := key(cand)
+
+			dist := distance(k, ck)
+			if strings.HasPrefix(ck, k) {
+				dist = 0
+			}
+
+			if dist < best {
+				best = dist
+			}
+		}
+
+		if best <= limit {
+			sugs = append(sugs, suggestion{cmd.Name, best})
+		}
+	}
+
+	sort.Slice(sugs, func(i, j int) bool {
+		if sugs[i].dist != sugs[j].dist {
+			return sugs[i].dist < sugs[j].dist
+		}
+
+		return sugs[i].name < sugs[j].name
+	})
+
+	if len(sugs) > max {
+		sugs = sugs[:max]
+	}
+
+	names := make([]string, len(sugs))
+	for i, sug := range sugs {
+		names[i] = sug.name
+	}
+
+	return names
+}
+
+// distance is the optimal string alignment distance between a and b,
+// which counts insertions, deletions, substitutions and adjacent transpositions.
+func distance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	rows := make([][]int, len(ar)+1)
+
+	for i := range rows {
+		rows[i] = make([]int, len(br)+1)
+		rows[i][0] = i
+	}
+
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			rows[i][j] = min3(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] && rows[i-2][j-2]+1 < rows[i][j] {
+				rows[i][j] = rows[i-2][j-2] + 1
+			}
+		}
+	}
+
+	return rows[len(ar)][len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
+
+// Suggest replies to the Trigger with Cmds similar to the one that wasn't found,
+// if the Guild has opted in through its GuildConf.
+func (t *Trigger) Suggest() error {
+	if !t.Route.Conf.For(t.Message.GuildID).Suggest {
+		return nil
+	}
+
+	name := t.Name
+	sugs := []string(nil)
+
+	switch {
+	case len(t.Path) == 0:
+		sugs = t.Route.Cmd.Suggest(name, DefaultSuggestions, false)
+	case len(t.Args) > 0:
+		name = t.Args[0]
+		sugs = t.Route.Cmd.SuggestSub(t.Command, name, DefaultSuggestions, false)
+
+		for i, sug := range sugs {
+			sugs[i] = t.PathName() + " " + sug
+		}
+	}
+
+	if len(sugs) == 0 {
+		return nil
+	}
+
+	rep := t.Reply()
+	rep.Content = "unknown command `" + name + "`, did you mean `" + strings.Join(sugs, "`, `") + "`?"
+
+	return rep.Send()
+}
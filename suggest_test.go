@@ -0,0 +1,85 @@
+package route_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/diamondburned/arikawa/v2/gateway"
+	"github.com/mavolin/dismock/v2/pkg/dismock"
+
+	"github.com/go-snart/route"
+)
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	c := route.NewCmdStore()
+
+	for _, name := range []string{"help", "hello", "ban", "prefix", "secret"} {
+		cmd, _ := testCmd()
+		cmd.Name = name
+		cmd.Hide = name == "secret"
+
+		c.Add(cmd)
+	}
+
+	for name, expect := range map[string][]string{
+		"hepl":   {"help", "hello"},
+		"hel":    {"hello", "help"},
+		"bna":    {"ban"},
+		"secrte": nil,
+		"zzzzzz": nil,
+	} {
+		got := c.Suggest(name, route.DefaultSuggestions, false)
+		if len(got) == 0 && len(expect) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, expect) {
+			t.Errorf("suggest %q: expect %v\ngot %v", name, expect, got)
+		}
+	}
+
+	if got := c.Suggest("secrte", 1, true); !reflect.DeepEqual(got, []string{"secret"}) {
+		t.Errorf("suggest hidden: expect %v\ngot %v", []string{"secret"}, got)
+	}
+}
+
+func TestHandleSuggest(t *testing.T) {
+	t.Parallel()
+
+	m, s := dismock.NewState(t)
+	c := testConfy()
+	r := testRoute(t, s, c)
+
+	cmd, _ := testCmd()
+	r.Cmd.Add(cmd)
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	r.Conf.Set(guild, route.GuildConf{Suggest: true})
+
+	m.Me(testMe)
+	m.Member(guild, testMMe)
+	m.SendMessage(nil, discord.Message{
+		ChannelID: channel,
+		Content:   "unknown command `cdm`, did you mean `cmd`?",
+	})
+
+	r.Handle(&gateway.MessageCreateEvent{
+		Message: discord.Message{
+			GuildID:   guild,
+			ChannelID: channel,
+			Author: discord.User{
+				ID: 999,
+			},
+			Content: testPfx.Value + "cdm",
+		},
+	})
+
+	m.Eval()
+}
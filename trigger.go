@@ -1,6 +1,7 @@
 package route
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -9,13 +10,9 @@ import (
 
 	"github.com/diamondburned/arikawa/v2/api"
 	"github.com/diamondburned/arikawa/v2/discord"
-	re2 "github.com/dlclark/regexp2"
 	ff "github.com/itzg/go-flagsfiller"
 )
 
-//nolint:gochecknoglobals // pre-compiling regexp
-var splitter = re2.MustCompile(`(\x60+)(.*?)\1|(\S+)`, 0)
-
 //nolint:gochecknoglobals // only needs to be set up once
 var filler = ff.New()
 
@@ -25,6 +22,12 @@ var (
 
 	// ErrNoCmd occurs when there is no command after the prefix.
 	ErrNoCmd = errors.New("no command")
+
+	// ErrCmdTimeout occurs when a command runs past its timeout.
+	ErrCmdTimeout = errors.New("command timed out")
+
+	// ErrCmdDisabled occurs when a command is disabled in the guild or channel it's called in.
+	ErrCmdDisabled = errors.New("command is disabled here")
 )
 
 // Func is a handler for a Trigger.
@@ -35,14 +38,32 @@ type Trigger struct {
 	Route   *Route
 	Message discord.Message
 	Prefix  Prefix
+	Name    string
 	Command Cmd
 	FlagSet *flag.FlagSet
 	Args    []string
 	Flags   interface{}
+	Pos     interface{}
 	Output  *strings.Builder
+
+	// Path holds each Cmd from the top-level Cmd down to Command.
+	Path []Cmd
+	// PathFlags holds the parsed Flags for each Cmd in Path.
+	PathFlags []interface{}
+
+	// Tokens holds every Token of the line after the prefix, including the command name.
+	Tokens []Token
+
+	// Body holds the raw lines of the message after the command's line, depending on Route.Dispatch.
+	Body string
+
+	shorts map[string]string
+	ctx    context.Context
 }
 
 // Trigger gets a Trigger by finding an appropriate Command for a given prefix, message, and line.
+//
+// On error, the returned Trigger may be partially filled, so that it can still be replied to.
 func (r *Route) Trigger(pfx Prefix, m discord.Message, line string) (*Trigger, error) {
 	//nolint:exhaustivestruct // this stuff will be filled
 	t := &Trigger{
@@ -50,48 +71,182 @@ func (r *Route) Trigger(pfx Prefix, m discord.Message, line string) (*Trigger, e
 		Message: m,
 		Prefix:  pfx,
 		Output:  &strings.Builder{},
+
+		ctx: r.Context,
 	}
 
-	line = strings.TrimSpace(strings.TrimPrefix(line, pfx.Value))
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), pfx.Value))
 	if len(line) == 0 {
-		return nil, ErrNoCmd
+		return t, ErrNoCmd
 	}
 
-	name, args := split(line)
+	toks, err := split(line)
+	if err != nil {
+		return t, fmt.Errorf("split: %w", err)
+	}
+
+	t.Tokens = toks
+	t.Name = toks[0].Text
+
+	args := make([]string, len(toks)-1)
+	for i, tok := range toks[1:] {
+		args[i] = tok.Text
+	}
 
-	cmd, ok := r.Cmd.Get(name)
+	cmd, ok := r.Cmd.Get(t.Name)
 	if !ok {
-		return nil, ErrCmdNotFound
+		return t, ErrCmdNotFound
 	}
 
-	t.Command = cmd
+	for {
+		t.Command = cmd
+		t.Path = append(t.Path, cmd)
 
-	flags, err := t.fillFlagSet()
-	if err != nil {
-		return nil, fmt.Errorf("fill: %w", err)
+		if r.Conf.Disabled(m.GuildID, m.ChannelID, t.PathName(), cmd.Cat) {
+			return t, ErrCmdDisabled
+		}
+
+		flags, err := t.fillFlagSet()
+		if err != nil {
+			return t, fmt.Errorf("fill: %w", err)
+		}
+
+		args, err = t.parseFlags(args)
+		if err != nil {
+			return t, fmt.Errorf("parse: %w", &FlagError{Err: err})
+		}
+
+		t.Flags = flags()
+		t.PathFlags = append(t.PathFlags, t.Flags)
+
+		if len(args) == 0 {
+			break
+		}
+
+		sub, ok := r.Cmd.Sub(cmd, args[0])
+		if !ok {
+			break
+		}
+
+		cmd, args = sub, args[1:]
 	}
 
-	err = t.FlagSet.Parse(args)
-	if err != nil {
-		return nil, fmt.Errorf("parse: %w", err)
+	t.Args = args
+
+	if t.Command.Func == nil {
+		if len(args) > 0 {
+			return t, ErrCmdNotFound
+		}
+
+		return t, ErrNoCmd
 	}
 
-	t.Flags = flags.Elem().Interface()
-	t.Args = t.FlagSet.Args()
+	if t.Command.Pos != nil {
+		err := t.fillPos()
+		if err != nil {
+			return t, fmt.Errorf("pos: %w", err)
+		}
+	}
 
 	return t, nil
 }
 
-func (t *Trigger) fillFlagSet() (reflect.Value, error) {
-	t.FlagSet = flag.NewFlagSet(t.Command.Name, flag.ContinueOnError)
+// Blocks gets the fenced code blocks from the Trigger's line.
+func (t *Trigger) Blocks() []Token {
+	blocks := []Token(nil)
+
+	for _, tok := range t.Tokens {
+		if tok.Fenced {
+			blocks = append(blocks, tok)
+		}
+	}
+
+	return blocks
+}
+
+// PathName gets the full name of the Trigger's Cmd, including its parents.
+func (t *Trigger) PathName() string {
+	names := make([]string, len(t.Path))
+
+	for i, cmd := range t.Path {
+		names[i] = cmd.Name
+	}
+
+	return strings.Join(names, " ")
+}
+
+// Context gets the Context for the Trigger, which is done once the Cmd should stop.
+func (t *Trigger) Context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+
+	return t.ctx
+}
+
+// Run calls the Trigger's Cmd, wrapped in the Middleware of it and its parents, as by Route.Chain.
+//
+// If the Cmd, its closest parent with one, or the Route has a Timeout, the Trigger's Context is
+// cancelled once it passes, and ErrCmdTimeout is returned.
+func (t *Trigger) Run() error {
+	path := t.Path
+	if len(path) == 0 {
+		path = []Cmd{t.Command}
+	}
+
+	timeout := t.Route.Timeout
+
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].Timeout != 0 {
+			timeout = path[i].Timeout
+
+			break
+		}
+	}
+
+	ctx := t.Context()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	t.ctx = ctx
+
+	err := t.Route.Chain(path)(t)
+
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if err == nil {
+			return fmt.Errorf("%w after %s", ErrCmdTimeout, timeout)
+		}
+
+		return fmt.Errorf("%w after %s: %s", ErrCmdTimeout, timeout, err)
+	}
+
+	return err
+}
+
+func (t *Trigger) fillFlagSet() (func() interface{}, error) {
+	t.FlagSet = flag.NewFlagSet(t.PathName(), flag.ContinueOnError)
 	t.FlagSet.SetOutput(t.Output)
 	t.FlagSet.Usage = t.Usage
 
-	flags := reflect.New(reflect.TypeOf(t.Command.Flags))
+	if t.Command.Flags == nil {
+		return func() interface{} { return nil }, nil
+	}
+
+	typ := reflect.TypeOf(t.Command.Flags)
 
-	err := filler.Fill(t.FlagSet, flags.Interface())
+	flags, err := t.fillFlags(typ)
 	if err != nil {
-		return reflect.ValueOf(nil), fmt.Errorf("fill flags: %w", err)
+		return nil, err
+	}
+
+	err = t.fillShorts(typ)
+	if err != nil {
+		return nil, fmt.Errorf("fill shorts: %w", err)
 	}
 
 	return flags, nil
@@ -106,20 +261,85 @@ func (t *Trigger) Usage() {
 	// discord types are excessive
 	// Fields can be nil for append
 	rep.Embed = &discord.Embed{
-		Title:       fmt.Sprintf("`%s` usage", t.Command.Name),
+		Title:       fmt.Sprintf("`%s` usage", t.PathName()),
 		Description: t.Command.Desc,
 	}
 
+	if t.Command.Pos != nil {
+		syn := t.Command
+		syn.Name = t.PathName()
+
+		rep.Embed.Fields = append(
+			rep.Embed.Fields, discord.EmbedField{
+				Name:   "usage",
+				Value:  "`" + t.Prefix.Clean + syn.Synopsis() + "`",
+				Inline: false,
+			},
+		)
+	}
+
+	if reqs := t.Command.Requires(); len(reqs) > 0 {
+		rep.Embed.Fields = append(
+			rep.Embed.Fields, discord.EmbedField{
+				Name:   "requires",
+				Value:  strings.Join(reqs, "\n"),
+				Inline: false,
+			},
+		)
+	}
+
+	dash, aliases := "-", map[string]bool{}
+	if t.Parser() == ParserGNU {
+		dash = "--"
+	}
+
+	for _, short := range t.shorts {
+		aliases[short] = true
+	}
+
 	t.FlagSet.VisitAll(func(f *flag.Flag) {
+		if aliases[f.Name] {
+			return
+		}
+
+		name := "`" + dash + f.Name + "`"
+		if short, ok := t.shorts[f.Name]; ok {
+			name = "`-" + short + "`, " + name
+		}
+
 		rep.Embed.Fields = append(
 			rep.Embed.Fields, discord.EmbedField{
-				Name:   "flag `-" + f.Name + "`",
+				Name:   "flag " + name,
 				Value:  f.Usage + "\ndefault: `" + f.DefValue + "`",
 				Inline: false,
 			},
 		)
 	})
 
+	if len(t.Command.Aliases) > 0 {
+		rep.Embed.Fields = append(
+			rep.Embed.Fields, discord.EmbedField{
+				Name:   "aliases",
+				Value:  "`" + strings.Join(t.Command.Aliases, "`, `") + "`",
+				Inline: false,
+			},
+		)
+	}
+
+	for _, sub := range t.Command.Subs {
+		if sub.Hide {
+			continue
+		}
+
+		rep.Embed.Fields = append(
+			rep.Embed.Fields, discord.EmbedField{
+				Name:   "subcommand `" + sub.Name + "`",
+				Value:  sub.Desc,
+				Inline: false,
+			},
+		)
+	}
+
 	// fuck it, no error check
 	_ = rep.Send()
 }
@@ -158,30 +378,3 @@ func (r *Reply) Send() error {
 
 	return err
 }
-
-func split(s string) (string, []string) {
-	subj := []rune(s)
-	args := []string(nil)
-
-	for {
-		// will only error if a timeout is set (it isn't)
-		m, _ := splitter.FindRunesMatch(subj)
-		gs := m.Groups()
-
-		match := gs[3].Capture.String()
-		if len(match) == 0 {
-			match = gs[2].Capture.String()
-		}
-
-		args = append(args, match)
-
-		l := gs[0].Capture.Length + 1
-		if l > len(subj) {
-			break
-		}
-
-		subj = subj[l:]
-	}
-
-	return args[0], args[1:]
-}
@@ -1,9 +1,11 @@
 package route_test
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/diamondburned/arikawa/v2/discord"
 	"github.com/mavolin/dismock/v2/pkg/dismock"
@@ -223,3 +225,194 @@ func TestTriggerFillError(t *testing.T) {
 
 	m.Eval()
 }
+
+func TestTriggerContextTimeout(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testCmd()
+	cmd.Timeout = time.Millisecond
+	cmd.Func = func(t *route.Trigger) error {
+		<-t.Context().Done()
+
+		return t.Context().Err()
+	}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if !errors.Is(err, route.ErrCmdTimeout) {
+		t.Errorf("expect %v\ngot %v", route.ErrCmdTimeout, err)
+	}
+}
+
+func TestTriggerSubTimeout(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+	r.Timeout = time.Hour
+
+	r.Cmd.Add(route.Cmd{
+		Name:    "admin",
+		Timeout: time.Millisecond,
+		Subs: []route.Cmd{{
+			Name: "ban",
+			Func: func(t *route.Trigger) error {
+				<-t.Context().Done()
+
+				return t.Context().Err()
+			},
+		}},
+	})
+
+	const line = "//admin ban"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if !errors.Is(err, route.ErrCmdTimeout) {
+		t.Errorf("expect %v\ngot %v", route.ErrCmdTimeout, err)
+	}
+}
+
+func TestTriggerContextCancel(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Context = ctx
+	r.Timeout = time.Hour
+
+	cmd, _ := testCmd()
+	cmd.Func = func(t *route.Trigger) error {
+		cancel()
+		<-t.Context().Done()
+
+		return t.Context().Err()
+	}
+	r.Cmd.Add(cmd)
+
+	const line = "//cmd"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	err = tr.Run()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expect %v\ngot %v", context.Canceled, err)
+	}
+}
+
+func TestTriggerDisabled(t *testing.T) {
+	t.Parallel()
+
+	const (
+		guild   = 123
+		channel = 456
+	)
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, called := testTreeCmd()
+	r.Cmd.Add(cmd)
+
+	r.Conf.SetDisabled(guild, route.GuildDisabled{
+		Channels: map[discord.ChannelID]route.Disabled{
+			channel: {Cats: []string{testCat}},
+		},
+	})
+
+	const line = "//prefix set"
+
+	m := discord.Message{GuildID: guild, ChannelID: channel, Content: line}
+
+	_, err := r.Trigger(testPfx, m, line)
+	if !errors.Is(err, route.ErrCmdDisabled) {
+		t.Errorf("expect ErrCmdDisabled, got %v", err)
+	}
+
+	m.ChannelID = 1
+
+	tr, err := r.Trigger(testPfx, m, line)
+	if err != nil {
+		t.Fatalf("trigger in other channel: %s", err)
+	}
+
+	err = tr.Run()
+	if err != nil || len(*called) != 1 {
+		t.Errorf("expect run, got %v %v", err, *called)
+	}
+}
+
+func TestTriggerSub(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, called := testTreeCmd()
+	r.Cmd.Add(cmd)
+
+	const line = "//prefix -global set -run=foo extra"
+
+	tr, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+	if err != nil {
+		t.Fatalf("trigger %q %q: %s", testPfx.Clean, line, err)
+	}
+
+	if name := tr.PathName(); name != "prefix set" {
+		t.Errorf("expect path %q, got %q", "prefix set", name)
+	}
+
+	if flags, _ := tr.PathFlags[0].(testSubFlags); !flags.Global {
+		t.Errorf("expect parent flags to be parsed, got %#v", tr.PathFlags[0])
+	}
+
+	if tr.Command.Cat != testCat {
+		t.Errorf("expect inherited cat %q, got %q", testCat, tr.Command.Cat)
+	}
+
+	if len(tr.Args) != 1 || tr.Args[0] != "extra" {
+		t.Errorf("expect args %v, got %v", []string{"extra"}, tr.Args)
+	}
+
+	err = tr.Run()
+	if err != nil {
+		t.Errorf("run: %s", err)
+	}
+
+	if len(*called) != 1 || (*called)[0] != "set foo" {
+		t.Errorf("expect %v\ngot %v", []string{"set foo"}, *called)
+	}
+}
+
+func TestTriggerSubMissing(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	cmd, _ := testTreeCmd()
+	r.Cmd.Add(cmd)
+
+	for line, expect := range map[string]error{
+		"//prefix":      route.ErrNoCmd,
+		"//prefix yeet": route.ErrCmdNotFound,
+	} {
+		_, err := r.Trigger(testPfx, discord.Message{Content: line}, line)
+		if !errors.Is(err, expect) {
+			t.Errorf("trigger %q %q: expect %v, got %v", testPfx.Clean, line, expect, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+)
+
+// ErrWatchInterval occurs when Watch is given an interval that isn't positive.
+var ErrWatchInterval = errors.New("watch interval must be positive")
+
+// PrefixScope is what a PrefixEvent applies to.
+type PrefixScope int
+
+const (
+	// PrefixGuild is for the prefixes of a Guild, or the global ones for GlobalGuildID.
+	PrefixGuild PrefixScope = iota
+	// PrefixChannel is for the prefixes of a channel.
+	PrefixChannel
+	// PrefixUser is for the prefixes of a user.
+	PrefixUser
+)
+
+// String gets the name of the PrefixScope.
+func (s PrefixScope) String() string {
+	switch s {
+	case PrefixGuild:
+		return "guild"
+	case PrefixChannel:
+		return "channel"
+	case PrefixUser:
+		return "user"
+	default:
+		return "unknown"
+	}
+}
+
+// PrefixEvent describes a change to the prefixes of a Guild, channel or user.
+type PrefixEvent struct {
+	Scope PrefixScope
+	// ID is the GuildID, ChannelID or UserID whose prefixes changed, depending on Scope.
+	ID discord.Snowflake
+
+	// Old and New are the PrefixRules before and after the change. Either is nil if there were none.
+	Old []PrefixRule
+	New []PrefixRule
+
+	// Remote is whether the change was found in the Confy by Reload or Store,
+	// rather than made through this PrefixStore.
+	Remote bool
+}
+
+// subscribers holds the funcs subscribed to a PrefixStore.
+type subscribers struct {
+	ma   map[int]func(PrefixEvent)
+	next int
+	mu   sync.Mutex
+}
+
+// Subscribe calls f with a PrefixEvent for every change to the PrefixStore, until the returned
+// func is called. This includes changes made through the PrefixStore and those found by Reload.
+//
+// f is called synchronously by whatever made the change, so it should return quickly.
+func (p *PrefixStore) Subscribe(f func(PrefixEvent)) func() {
+	p.subs.mu.Lock()
+	defer p.subs.mu.Unlock()
+
+	if p.subs.ma == nil {
+		p.subs.ma = map[int]func(PrefixEvent){}
+	}
+
+	id := p.subs.next
+	p.subs.next++
+	p.subs.ma[id] = f
+
+	return func() {
+		p.subs.mu.Lock()
+		delete(p.subs.ma, id)
+		p.subs.mu.Unlock()
+	}
+}
+
+func (p *PrefixStore) emit(e PrefixEvent) {
+	p.subs.mu.Lock()
+	fs := make([]func(PrefixEvent), 0, len(p.subs.ma))
+
+	for _, f := range p.subs.ma {
+		fs = append(fs, f)
+	}
+	p.subs.mu.Unlock()
+
+	if len(fs) == 0 {
+		return
+	}
+
+	e.Old = append([]PrefixRule(nil), e.Old...)
+	e.New = append([]PrefixRule(nil), e.New...)
+
+	for _, f := range fs {
+		f(e)
+	}
+}
+
+// prefixKey identifies the PrefixRules of a Guild, channel or user.
+type prefixKey struct {
+	Scope PrefixScope
+	ID    discord.Snowflake
+}
+
+// Reload updates the PrefixStore with data from the Confy, and emits a PrefixEvent
+// for everything that differs. This picks up changes stored by other processes.
+//
+// Changes waiting for AutoStore are flushed first. Entries changed through this PrefixStore
+// that haven't been stored yet are kept, rather than replaced by those in the Confy.
+func (p *PrefixStore) Reload() error {
+	err := p.Flush()
+	if err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	fresh, err := p.fresh()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	events := p.merge(fresh)
+	p.mu.Unlock()
+
+	for _, e := range events {
+		p.emit(e)
+	}
+
+	return nil
+}
+
+// fresh loads a new PrefixStore from the Confy.
+func (p *PrefixStore) fresh() (*PrefixStore, error) {
+	//nolint:exhaustivestruct // only used to load into
+	fresh := &PrefixStore{
+		Confy: p.Confy,
+
+		ma: map[discord.GuildID]prefixList{},
+		ch: map[discord.ChannelID]prefixList{},
+		us: map[discord.UserID]prefixList{},
+	}
+
+	err := fresh.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return fresh, nil
+}
+
+// merge takes the entries from fresh that differ, except those changed through the PrefixStore
+// since it last stored, and returns a PrefixEvent for each. p.mu must be held.
+func (p *PrefixStore) merge(fresh *PrefixStore) []PrefixEvent {
+	type change struct {
+		e   PrefixEvent
+		has bool
+	}
+
+	changes := []change(nil)
+	seen := map[prefixKey]bool{}
+
+	diff := func(scope PrefixScope, id discord.Snowflake) {
+		key := prefixKey{Scope: scope, ID: id}
+		if _, local := p.local[key]; seen[key] || local {
+			return
+		}
+
+		seen[key] = true
+
+		old, had := p.get(scope, id)
+		rules, has := fresh.get(scope, id)
+
+		if had != has || !old.equal(rules) {
+			e := PrefixEvent{Scope: scope, ID: id, Old: old, New: rules, Remote: true}
+			changes = append(changes, change{e: e, has: has})
+		}
+	}
+
+	for _, ma := range []map[discord.GuildID]prefixList{p.ma, fresh.ma} {
+		for g := range ma {
+			diff(PrefixGuild, discord.Snowflake(g))
+		}
+	}
+
+	for _, ma := range []map[discord.ChannelID]prefixList{p.ch, fresh.ch} {
+		for ch := range ma {
+			diff(PrefixChannel, discord.Snowflake(ch))
+		}
+	}
+
+	for _, ma := range []map[discord.UserID]prefixList{p.us, fresh.us} {
+		for u := range ma {
+			diff(PrefixUser, discord.Snowflake(u))
+		}
+	}
+
+	events := make([]PrefixEvent, len(changes))
+
+	for i, c := range changes {
+		p.put(c.e.Scope, c.e.ID, c.e.New, c.has)
+		events[i] = c.e
+	}
+
+	return events
+}
+
+// Watch calls Reload every interval, until the Context is done.
+// Errors from Reload are passed to onErr, if it isn't nil.
+//
+// Reload can also be called directly, for backends that can tell when they've changed.
+//
+// The interval must be positive. Otherwise, Watch returns right away, passing ErrWatchInterval to onErr.
+func (p *PrefixStore) Watch(ctx context.Context, interval time.Duration, onErr func(error)) {
+	if interval <= 0 {
+		if onErr != nil {
+			onErr(fmt.Errorf("%w: %s", ErrWatchInterval, interval))
+		}
+
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.Reload()
+			if err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
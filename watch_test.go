@@ -0,0 +1,413 @@
+package route_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diamondburned/arikawa/v2/discord"
+	"github.com/superloach/confy"
+
+	"github.com/go-snart/route"
+)
+
+// testEvents collects PrefixEvents from a subscription.
+type testEvents struct {
+	mu     sync.Mutex
+	events []route.PrefixEvent
+}
+
+func (e *testEvents) add(ev route.PrefixEvent) {
+	e.mu.Lock()
+	e.events = append(e.events, ev)
+	e.mu.Unlock()
+}
+
+func (e *testEvents) take() []route.PrefixEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	events := e.events
+	e.events = nil
+
+	return events
+}
+
+func testRuleValues(rules []route.PrefixRule) []string {
+	if rules == nil {
+		return nil
+	}
+
+	pfxvs := make([]string, len(rules))
+	for i, rule := range rules {
+		pfxvs[i] = rule.Value
+	}
+
+	return pfxvs
+}
+
+func testSharedStores(t *testing.T) (*route.PrefixStore, *route.PrefixStore) {
+	t.Helper()
+
+	c := testConfy()
+
+	a, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open a: %s", err)
+	}
+
+	b, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open b: %s", err)
+	}
+
+	return a, b
+}
+
+func TestPrefixSubscribe(t *testing.T) {
+	t.Parallel()
+
+	r := testRoute(t, nil, testConfy())
+
+	events := &testEvents{}
+	cancel := r.Prefix.Subscribe(events.add)
+
+	r.Prefix.Set(1, "!")
+	r.Prefix.Add(1, "!")
+	r.Prefix.Add(1, "?")
+	r.Prefix.Remove(1, "!")
+	r.Prefix.DelUser(2)
+	r.Prefix.SetUser(2, "yo ")
+
+	got := events.take()
+	expect := []struct {
+		scope    route.PrefixScope
+		id       discord.Snowflake
+		old, new []string
+	}{
+		{route.PrefixGuild, 1, nil, []string{"!"}},
+		{route.PrefixGuild, 1, []string{"!"}, []string{"!", "?"}},
+		{route.PrefixGuild, 1, []string{"!", "?"}, []string{"?"}},
+		{route.PrefixUser, 2, nil, []string{"yo "}},
+	}
+
+	if len(got) != len(expect) {
+		t.Fatalf("expect %d events, got %#v", len(expect), got)
+	}
+
+	for i, e := range expect {
+		ev := got[i]
+		if ev.Scope != e.scope || ev.ID != e.id || ev.Remote ||
+			!reflect.DeepEqual(testRuleValues(ev.Old), e.old) ||
+			!reflect.DeepEqual(testRuleValues(ev.New), e.new) {
+			t.Errorf("event %d: expect %v, got %#v", i, e, ev)
+		}
+	}
+
+	cancel()
+	r.Prefix.Del(1)
+
+	if got := events.take(); len(got) != 0 {
+		t.Errorf("expect no events after cancel, got %#v", got)
+	}
+}
+
+func TestPrefixReload(t *testing.T) {
+	t.Parallel()
+
+	a, b := testSharedStores(t)
+
+	events := &testEvents{}
+	b.Subscribe(events.add)
+
+	a.Set(1, "!")
+	a.SetChannel(2, "$")
+
+	err := a.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	err = b.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	got := map[route.PrefixScope][]string{}
+	for _, ev := range events.take() {
+		if !ev.Remote || ev.Old != nil {
+			t.Errorf("expect remote new event, got %#v", ev)
+		}
+
+		got[ev.Scope] = testRuleValues(ev.New)
+	}
+
+	expect := map[route.PrefixScope][]string{
+		route.PrefixGuild:   {"!"},
+		route.PrefixChannel: {"$"},
+	}
+
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect events %v, got %v", expect, got)
+	}
+
+	if pfxvs, _ := b.GetAll(1); !reflect.DeepEqual(pfxvs, []string{"!"}) {
+		t.Errorf("expect reloaded prefixes, got %q", pfxvs)
+	}
+
+	a.Del(1)
+
+	err = a.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	err = b.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	evs := events.take()
+	if len(evs) != 1 || evs[0].ID != 1 || evs[0].New != nil ||
+		!reflect.DeepEqual(testRuleValues(evs[0].Old), []string{"!"}) {
+		t.Errorf("expect delete event, got %#v", evs)
+	}
+
+	if _, ok := b.Get(1); ok {
+		t.Error("expect prefixes deleted")
+	}
+
+	err = b.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	if evs := events.take(); len(evs) != 0 {
+		t.Errorf("expect no events without changes, got %#v", evs)
+	}
+}
+
+func TestPrefixReloadFlushes(t *testing.T) {
+	t.Parallel()
+
+	a, b := testSharedStores(t)
+
+	b.AutoStore(time.Hour, nil)
+	b.Set(5, "x")
+
+	err := b.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	if _, ok := b.Get(5); !ok {
+		t.Error("expect pending change to survive reload")
+	}
+
+	err = a.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	if _, ok := a.Get(5); !ok {
+		t.Error("expect pending change to be stored")
+	}
+}
+
+func TestPrefixStoreMerge(t *testing.T) {
+	t.Parallel()
+
+	a, b := testSharedStores(t)
+
+	events := &testEvents{}
+	a.Subscribe(events.add)
+
+	a.Set(1, "!")
+
+	err := a.Store()
+	if err != nil {
+		t.Fatalf("store a: %s", err)
+	}
+
+	b.Set(2, "?")
+
+	err = b.Store()
+	if err != nil {
+		t.Fatalf("store b: %s", err)
+	}
+
+	if pfxvs, _ := b.GetAll(1); !reflect.DeepEqual(pfxvs, []string{"!"}) {
+		t.Errorf("expect b to pick up prefixes from a, got %q", pfxvs)
+	}
+
+	a.Set(3, "$")
+	events.take()
+
+	err = a.Reload()
+	if err != nil {
+		t.Fatalf("reload a: %s", err)
+	}
+
+	evs := events.take()
+	if len(evs) != 1 || evs[0].ID != 2 || !evs[0].Remote {
+		t.Errorf("expect remote event for 2, got %#v", evs)
+	}
+
+	for g, expect := range map[discord.GuildID]string{1: "!", 2: "?", 3: "$"} {
+		if pfxv, _ := a.Get(g); pfxv != expect {
+			t.Errorf("a: expect %q for %d, got %q", expect, g, pfxv)
+		}
+	}
+
+	b.Set(3, "%")
+
+	err = b.Store()
+	if err != nil {
+		t.Fatalf("store b: %s", err)
+	}
+
+	err = a.Reload()
+	if err != nil {
+		t.Fatalf("reload a: %s", err)
+	}
+
+	if pfxv, _ := a.Get(3); pfxv != "$" {
+		t.Errorf("expect unstored prefix to survive reload, got %q", pfxv)
+	}
+
+	err = a.Store()
+	if err != nil {
+		t.Fatalf("store a: %s", err)
+	}
+
+	err = b.Reload()
+	if err != nil {
+		t.Fatalf("reload b: %s", err)
+	}
+
+	for g, expect := range map[discord.GuildID]string{1: "!", 2: "?", 3: "$"} {
+		if pfxv, _ := b.Get(g); pfxv != expect {
+			t.Errorf("b: expect %q for %d, got %q", expect, g, pfxv)
+		}
+	}
+}
+
+// testBlockConfy blocks storing prefixes until released.
+type testBlockConfy struct {
+	confy.Confy
+
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *testBlockConfy) Set(key string, val interface{}) error {
+	if key == route.KeyPrefix {
+		c.entered <- struct{}{}
+		<-c.release
+	}
+
+	return c.Confy.Set(key, val)
+}
+
+func TestPrefixStoreUnlocked(t *testing.T) {
+	t.Parallel()
+
+	c := &testBlockConfy{
+		Confy:   testConfy(),
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	a, err := route.OpenPrefixStore(c)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	a.Set(1, "!")
+
+	done := make(chan error)
+	go func() { done <- a.Store() }()
+
+	<-c.entered
+
+	// reads and changes don't wait for the Confy
+	if _, ok := a.ForLine(1, testMe, nil, "!cmd"); !ok {
+		t.Error("expect prefix to match while storing")
+	}
+
+	a.Set(2, "?")
+	close(c.release)
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	b, err := route.OpenPrefixStore(c.Confy)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+
+	b.Set(2, "%")
+
+	err = b.Store()
+	if err != nil {
+		t.Fatalf("store b: %s", err)
+	}
+
+	err = a.Reload()
+	if err != nil {
+		t.Fatalf("reload: %s", err)
+	}
+
+	if pfxv, _ := a.Get(2); pfxv != "?" {
+		t.Errorf("expect change made while storing to stay local, got %q", pfxv)
+	}
+}
+
+func TestPrefixWatch(t *testing.T) {
+	t.Parallel()
+
+	a, b := testSharedStores(t)
+
+	events := make(chan route.PrefixEvent, 1)
+	b.Subscribe(func(ev route.PrefixEvent) { events <- ev })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go b.Watch(ctx, 5*time.Millisecond, func(err error) { t.Errorf("watch: %s", err) })
+
+	a.SetUser(3, "?")
+
+	err := a.Store()
+	if err != nil {
+		t.Fatalf("store: %s", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Scope != route.PrefixUser || ev.ID != 3 || !ev.Remote {
+			t.Errorf("unexpected event %#v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect event from watch")
+	}
+}
+
+func TestPrefixWatchInterval(t *testing.T) {
+	t.Parallel()
+
+	a, _ := testSharedStores(t)
+
+	var err error
+
+	a.Watch(context.Background(), 0, func(werr error) { err = werr })
+
+	if !errors.Is(err, route.ErrWatchInterval) {
+		t.Errorf("expect %v\ngot %v", route.ErrWatchInterval, err)
+	}
+}